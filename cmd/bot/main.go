@@ -2,6 +2,8 @@ package main
 
 import (
 	"log"
+	"math/rand"
+	"time"
 
 	"cryptowordgamebot/internal/bot"
 	"cryptowordgamebot/internal/config"
@@ -33,13 +35,20 @@ func main() {
 	}
 	log.Println("Storage initialized successfully.")
 
+	puzzleStore := storage.NewPuzzleStateRepository(db)
+	duelStore := storage.NewDuelRepository(db)
+
 	translator, err := i18n.New("locales", cfg.DefaultLanguage)
 	if err != nil {
 		log.Fatalf("Failed to initialize translator: %v", err)
 	}
 	log.Println("Translator initialized successfully.")
 
-	gameSvc := game.NewService(gameCfg)
+	seed := cfg.GameSeed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	gameSvc := game.NewService(gameCfg, rand.NewSource(seed))
 
 	themeCfg, err := game.LoadThemes("themes.yaml")
 	if err != nil {
@@ -59,14 +68,12 @@ func main() {
 	}
 	log.Printf("Authorized on account %s", api.Self.UserName)
 
-	handler := bot.NewBotHandler(api, translator, cfg, db, gameSvc, themeCfg, powerupCfg)
+	handler := bot.NewBotHandler(api, translator, cfg, db, puzzleStore, duelStore, gameSvc, themeCfg, powerupCfg)
 
 	u := tgbotapi.NewUpdate(0)
 	u.Timeout = 60
 	u.AllowedUpdates = []string{"message", "callback_query"}
 	updates := api.GetUpdatesChan(u)
 
-	for update := range updates {
-		handler.HandleUpdate(update)
-	}
+	handler.Run(updates)
 }
\ No newline at end of file
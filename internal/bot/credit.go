@@ -0,0 +1,49 @@
+package bot
+
+import (
+	"html"
+	"log"
+	"strconv"
+	"strings"
+
+	"cryptowordgamebot/internal/game"
+	"cryptowordgamebot/internal/storage"
+)
+
+// creditBreakdown lists how the reward of a puzzle was split when more than
+// one player filled in its letters, or returns an empty string otherwise.
+func (h *BotHandler) creditBreakdown(finisher *storage.User, shares []game.Share) string {
+	if len(shares) < 2 {
+		return ""
+	}
+	var breakdownBuilder strings.Builder
+	breakdownBuilder.WriteString("\n\n" + h.translator.Translate(finisher.LanguageCode, "credit_title", nil))
+	for _, share := range shares {
+		name := finisher.FirstName
+		if share.UserID != finisher.ID {
+			name = h.playerName(share.UserID)
+		}
+		params := map[string]string{
+			"name":    html.EscapeString(name),
+			"letters": strconv.Itoa(share.Letters),
+			"points":  strconv.Itoa(share.Points),
+		}
+		entryKey := "credit_entry"
+		if share.Finisher {
+			entryKey = "credit_entry_finisher"
+		}
+		breakdownBuilder.WriteString("\n" + h.translator.Translate(finisher.LanguageCode, entryKey, params))
+	}
+	return breakdownBuilder.String()
+}
+
+// playerName returns the first name of a user, or their ID if they cannot be
+// loaded.
+func (h *BotHandler) playerName(userID int64) string {
+	player, err := h.storage.GetUser(userID)
+	if err != nil {
+		log.Printf("Failed to get user %d for credit: %v", userID, err)
+		return strconv.FormatInt(userID, 10)
+	}
+	return player.FirstName
+}
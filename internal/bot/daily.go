@@ -0,0 +1,121 @@
+package bot
+
+import (
+	"html"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"cryptowordgamebot/internal/game"
+	"cryptowordgamebot/internal/storage"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func (h *BotHandler) handleDailyCommand(message *tgbotapi.Message, user *storage.User) {
+	today := game.DailyKey(time.Now())
+
+	args := strings.ToLower(strings.TrimSpace(message.CommandArguments()))
+	if args == "top" || args == "rank" {
+		h.sendDailyRanking(message.Chat.ID, user, today)
+		return
+	}
+
+	if !message.Chat.IsPrivate() {
+		responseText := h.translator.Translate(user.LanguageCode, "daily_private_only", nil)
+		h.sendMessage(message.Chat.ID, responseText, "")
+		return
+	}
+
+	if h.activeDuel(user.ID) != nil {
+		responseText := h.translator.Translate(user.LanguageCode, "duel_in_progress", nil)
+		h.sendMessage(message.Chat.ID, responseText, "")
+		return
+	}
+	h.mu.Lock()
+	_, isActive := h.activePuzzles[message.Chat.ID]
+	h.mu.Unlock()
+	if isActive {
+		responseText := h.translator.Translate(user.LanguageCode, "daily_finish_current", nil)
+		h.sendMessage(message.Chat.ID, responseText, "")
+		return
+	}
+
+	attempt, err := h.storage.GetDailyResult(user.ID, today)
+	if err != nil {
+		log.Printf("Failed to get daily result for user %d: %v", user.ID, err)
+		return
+	}
+	if attempt != nil {
+		responseText := h.translator.Translate(user.LanguageCode, "daily_already_played", nil)
+		h.sendMessage(message.Chat.ID, responseText, tgbotapi.ModeHTML)
+		return
+	}
+
+	puzzle, err := h.gameSvc.GenerateDailyPuzzle(time.Now())
+	if err != nil {
+		log.Printf("Failed to generate daily puzzle: %v", err)
+		return
+	}
+
+	if err := h.storage.StartDailyAttempt(user.ID, user.FirstName, today); err != nil {
+		log.Printf("Failed to start daily attempt for user %d: %v", user.ID, err)
+		return
+	}
+
+	responseText := h.translator.Translate(user.LanguageCode, "daily_intro", map[string]string{"date": today})
+	h.sendMessage(message.Chat.ID, responseText, tgbotapi.ModeHTML)
+	if !h.startPuzzle(message.Chat.ID, user, puzzle) {
+		// The puzzle never reached the player, so the attempt is not spent.
+		if err := h.storage.DeleteDailyAttempt(user.ID, today); err != nil {
+			log.Printf("Failed to roll back daily attempt for user %d: %v", user.ID, err)
+		}
+	}
+}
+
+func (h *BotHandler) finishDailyAttempt(chatID int64, user *storage.User, puzzle *game.Puzzle, solved bool) {
+	result, err := h.storage.FinishDailyAttempt(user.ID, puzzle.DailyDate, solved, puzzle.Guesses)
+	if err != nil {
+		log.Printf("Failed to finish daily attempt for user %d: %v", user.ID, err)
+		return
+	}
+	if !solved {
+		return
+	}
+
+	params := map[string]string{
+		"time":    formatSeconds(result.SolveSeconds),
+		"guesses": strconv.Itoa(result.Guesses),
+	}
+	responseText := h.translator.Translate(user.LanguageCode, "daily_solved", params)
+	h.sendMessage(chatID, responseText, tgbotapi.ModeHTML)
+}
+
+func (h *BotHandler) sendDailyRanking(chatID int64, user *storage.User, date string) {
+	results, err := h.storage.GetDailyRanking(date, 10)
+	if err != nil {
+		log.Printf("Failed to get daily ranking: %v", err)
+		return
+	}
+
+	var rankingBuilder strings.Builder
+	rankingBuilder.WriteString(h.translator.Translate(user.LanguageCode, "daily_leaderboard_title", map[string]string{"date": date}))
+	if len(results) == 0 {
+		rankingBuilder.WriteString(h.translator.Translate(user.LanguageCode, "daily_leaderboard_empty", nil))
+	}
+	for i, result := range results {
+		params := map[string]string{
+			"rank":    strconv.Itoa(i + 1),
+			"name":    html.EscapeString(result.FirstName),
+			"time":    formatSeconds(result.SolveSeconds),
+			"guesses": strconv.Itoa(result.Guesses),
+		}
+		rankingBuilder.WriteString(h.translator.Translate(user.LanguageCode, "daily_leaderboard_entry", params))
+	}
+	h.sendMessage(chatID, rankingBuilder.String(), tgbotapi.ModeHTML)
+}
+
+func formatSeconds(seconds int) string {
+	return (time.Duration(seconds) * time.Second).String()
+}
@@ -0,0 +1,499 @@
+package bot
+
+import (
+	"html"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"cryptowordgamebot/internal/game"
+	"cryptowordgamebot/internal/storage"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// handleDuelCommand challenges another player, /duel @username [level] [bet].
+// The bet is taken from the challenger at once and held until the duel ends.
+func (h *BotHandler) handleDuelCommand(message *tgbotapi.Message, user *storage.User) {
+	fields := strings.Fields(message.CommandArguments())
+	if len(fields) == 0 || !strings.HasPrefix(fields[0], "@") {
+		responseText := h.translator.Translate(user.LanguageCode, "duel_usage", nil)
+		h.sendMessage(message.Chat.ID, responseText, tgbotapi.ModeHTML)
+		return
+	}
+
+	duelCfg := h.gameSvc.Config().Duel
+	difficulty := h.gameSvc.Config().DefaultDifficulty
+	bet := 0
+	for _, field := range fields[1:] {
+		if n, err := strconv.Atoi(field); err == nil {
+			bet = n
+			continue
+		}
+		resolved, ok := h.resolveDifficultyArg(message.Chat.ID, user, field)
+		if !ok {
+			return
+		}
+		difficulty = resolved
+	}
+	if bet < 0 || (duelCfg.MaxBet > 0 && bet > duelCfg.MaxBet) {
+		params := map[string]string{"max": strconv.Itoa(duelCfg.MaxBet)}
+		responseText := h.translator.Translate(user.LanguageCode, "duel_invalid_bet", params)
+		h.sendMessage(message.Chat.ID, responseText, tgbotapi.ModeHTML)
+		return
+	}
+	if int64(bet) > user.Score {
+		params := map[string]string{"score": strconv.FormatInt(user.Score, 10)}
+		responseText := h.translator.Translate(user.LanguageCode, "duel_not_enough_points", params)
+		h.sendMessage(message.Chat.ID, responseText, tgbotapi.ModeHTML)
+		return
+	}
+
+	opponent, err := h.storage.GetUserByUsername(fields[0])
+	if err != nil {
+		params := map[string]string{"username": html.EscapeString(fields[0])}
+		responseText := h.translator.Translate(user.LanguageCode, "duel_unknown_user", params)
+		h.sendMessage(message.Chat.ID, responseText, tgbotapi.ModeHTML)
+		return
+	}
+	if opponent.ID == user.ID {
+		responseText := h.translator.Translate(user.LanguageCode, "duel_self", nil)
+		h.sendMessage(message.Chat.ID, responseText, "")
+		return
+	}
+	if h.playerDuel(user.ID) != nil || h.playerDuel(opponent.ID) != nil {
+		responseText := h.translator.Translate(user.LanguageCode, "duel_busy", nil)
+		h.sendMessage(message.Chat.ID, responseText, "")
+		return
+	}
+
+	if bet > 0 {
+		if _, err := h.storage.IncreaseUserScore(user.ID, -bet); err != nil {
+			log.Printf("Failed to escrow duel bet of user %d: %v", user.ID, err)
+			return
+		}
+	}
+	duel := game.NewDuel(user.ID, opponent.ID, message.Chat.ID, difficulty, bet, time.Now())
+
+	// A challenge made in a private chat goes to the opponent's private chat,
+	// one made in a group is answered in the group.
+	challengeChatID, challengeLang := message.Chat.ID, user.LanguageCode
+	if message.Chat.IsPrivate() {
+		challengeChatID, challengeLang = opponent.ID, opponent.LanguageCode
+	}
+	sentMsg, err := h.sendDuelChallenge(challengeChatID, challengeLang, duel, user, opponent)
+	if err != nil {
+		log.Printf("Failed to send duel challenge to chat %d: %v", challengeChatID, err)
+		h.refundDuel(duel, user.ID)
+		params := map[string]string{"name": html.EscapeString(opponent.FirstName)}
+		responseText := h.translator.Translate(user.LanguageCode, "duel_unreachable", params)
+		h.sendMessage(message.Chat.ID, responseText, tgbotapi.ModeHTML)
+		return
+	}
+	duel.ChatID, duel.MessageID = challengeChatID, sentMsg.MessageID
+
+	h.mu.Lock()
+	h.duels[duel.ID] = duel
+	h.mu.Unlock()
+	h.saveDuel(duel)
+
+	if message.Chat.IsPrivate() {
+		params := map[string]string{"name": html.EscapeString(opponent.FirstName)}
+		responseText := h.translator.Translate(user.LanguageCode, "duel_challenge_sent", params)
+		h.sendMessage(message.Chat.ID, responseText, tgbotapi.ModeHTML)
+	}
+}
+
+func (h *BotHandler) sendDuelChallenge(chatID int64, lang string, duel *game.Duel, challenger, opponent *storage.User) (tgbotapi.Message, error) {
+	params := map[string]string{
+		"challenger": html.EscapeString(challenger.FirstName),
+		"opponent":   html.EscapeString(opponent.FirstName),
+		"level":      h.gameSvc.Config().DifficultyName(duel.Difficulty, lang),
+		"timeout":    formatSeconds(int(h.gameSvc.Config().Duel.AcceptTimeout.Seconds())),
+	}
+	text := h.translator.Translate(lang, "duel_challenge", params)
+	if duel.Bet > 0 {
+		betParams := map[string]string{"bet": strconv.Itoa(duel.Bet)}
+		text += "\n" + h.translator.Translate(lang, "duel_bet_note", betParams)
+	}
+
+	acceptButton := tgbotapi.NewInlineKeyboardButtonData(h.translator.Translate(lang, "duel_accept_button", nil), "duel_accept_"+duel.ID)
+	declineButton := tgbotapi.NewInlineKeyboardButtonData(h.translator.Translate(lang, "duel_decline_button", nil), "duel_decline_"+duel.ID)
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = tgbotapi.ModeHTML
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(acceptButton, declineButton))
+	return h.bot.Send(msg)
+}
+
+// handleDuelCallback handles the accept and decline buttons of a challenge,
+// which only the challenged player may press.
+func (h *BotHandler) handleDuelCallback(query *tgbotapi.CallbackQuery, user *storage.User) {
+	parts := strings.SplitN(query.Data, "_", 3)
+	if len(parts) != 3 {
+		return
+	}
+	action, id := parts[1], parts[2]
+
+	h.mu.Lock()
+	duel, ok := h.duels[id]
+	pending := ok && duel.State == game.DuelPending
+	h.mu.Unlock()
+	if !pending {
+		h.answerCallbackAlert(query, h.translator.Translate(user.LanguageCode, "duel_not_available", nil))
+		return
+	}
+	if user.ID != duel.OpponentID {
+		h.answerCallbackAlert(query, h.translator.Translate(user.LanguageCode, "duel_not_for_you", nil))
+		return
+	}
+	h.bot.Request(tgbotapi.NewCallback(query.ID, ""))
+
+	switch action {
+	case "accept":
+		h.acceptDuel(duel, user)
+	case "decline":
+		h.declineDuel(duel, user)
+	}
+}
+
+func (h *BotHandler) answerCallbackAlert(query *tgbotapi.CallbackQuery, text string) {
+	callback := tgbotapi.NewCallbackWithAlert(query.ID, text)
+	h.bot.Request(callback)
+}
+
+func (h *BotHandler) declineDuel(duel *game.Duel, opponent *storage.User) {
+	h.mu.Lock()
+	err := duel.Decline()
+	if err == nil {
+		delete(h.duels, duel.ID)
+	}
+	h.mu.Unlock()
+	if err != nil {
+		return
+	}
+	h.deleteDuel(duel.ID)
+	h.refundDuel(duel, duel.ChallengerID)
+
+	params := map[string]string{"name": html.EscapeString(opponent.FirstName)}
+	text := h.translator.Translate(opponent.LanguageCode, "duel_declined", params)
+	h.editMessage(duel.ChatID, duel.MessageID, text, tgbotapi.ModeHTML)
+	if duel.ChatID == duel.OpponentID {
+		challenger := h.chatUser(duel.ChallengerID)
+		h.sendMessage(duel.ChallengerID, h.translator.Translate(challenger.LanguageCode, "duel_declined", params), tgbotapi.ModeHTML)
+	}
+}
+
+// acceptDuel takes the opponent's bet, starts the duel and sends both players
+// their copy of the puzzle. A player still busy with a puzzle in their private
+// chat, such as the daily, has to finish it first, as the duel would take
+// over their guesses.
+func (h *BotHandler) acceptDuel(duel *game.Duel, opponent *storage.User) {
+	h.mu.Lock()
+	var busyID int64
+	for _, playerID := range []int64{duel.OpponentID, duel.ChallengerID} {
+		if _, ok := h.activePuzzles[playerID]; ok {
+			busyID = playerID
+			break
+		}
+	}
+	h.mu.Unlock()
+	if busyID != 0 {
+		params := map[string]string{"name": html.EscapeString(h.chatUser(busyID).FirstName)}
+		responseText := h.translator.Translate(opponent.LanguageCode, "duel_player_busy", params)
+		h.sendMessage(duel.ChatID, responseText, tgbotapi.ModeHTML)
+		return
+	}
+	if int64(duel.Bet) > opponent.Score {
+		params := map[string]string{"score": strconv.FormatInt(opponent.Score, 10)}
+		responseText := h.translator.Translate(opponent.LanguageCode, "duel_not_enough_points", params)
+		h.sendMessage(duel.ChatID, responseText, tgbotapi.ModeHTML)
+		return
+	}
+
+	recent := h.mergeRecentPuzzles(
+		historyScope{storage.HistoryScopeUser, duel.ChallengerID},
+		historyScope{storage.HistoryScopeUser, duel.OpponentID},
+	)
+	h.mu.Lock()
+	err := h.gameSvc.StartDuel(duel, recent, time.Now())
+	h.mu.Unlock()
+	if err != nil {
+		log.Printf("Failed to start duel %s: %v", duel.ID, err)
+		return
+	}
+	if duel.Bet > 0 {
+		if _, err := h.storage.IncreaseUserScore(opponent.ID, -duel.Bet); err != nil {
+			log.Printf("Failed to escrow duel bet of user %d: %v", opponent.ID, err)
+			h.cancelDuel(duel, duel.ChallengerID)
+			return
+		}
+	}
+
+	challenger := h.chatUser(duel.ChallengerID)
+	params := map[string]string{
+		"challenger": html.EscapeString(challenger.FirstName),
+		"opponent":   html.EscapeString(opponent.FirstName),
+	}
+	text := h.translator.Translate(opponent.LanguageCode, "duel_accepted", params)
+	h.editMessage(duel.ChatID, duel.MessageID, text, tgbotapi.ModeHTML)
+
+	for _, player := range []*storage.User{challenger, opponent} {
+		if !h.sendDuelPuzzle(duel, player) {
+			h.cancelDuel(duel, duel.ChallengerID, duel.OpponentID)
+			return
+		}
+	}
+	h.saveDuel(duel)
+}
+
+// sendDuelPuzzle sends a player their copy of the duel puzzle in their
+// private chat and reports whether it arrived.
+func (h *BotHandler) sendDuelPuzzle(duel *game.Duel, player *storage.User) bool {
+	puzzle := duel.Puzzles[player.ID]
+	params := map[string]string{
+		"opponent": html.EscapeString(h.chatUser(duel.Opponent(player.ID)).FirstName),
+		"cipher":   h.translator.Translate(player.LanguageCode, "cipher_"+puzzle.Cipher.Name, nil),
+		"count":    strconv.Itoa(utf8.RuneCountInString(puzzle.Solution)),
+		"time":     formatSeconds(int(puzzle.TimeLimit.Seconds())),
+	}
+	introText := h.translator.Translate(player.LanguageCode, "duel_started", params)
+	if _, err := h.sendMessage(player.ID, introText, tgbotapi.ModeHTML); err != nil {
+		log.Printf("Failed to send duel intro to user %d: %v", player.ID, err)
+		return false
+	}
+
+	puzzle.Style = h.displayStyle(player.ID, player)
+	sentMsg, err := h.sendPuzzleMessage(player.ID, puzzle)
+	if err != nil {
+		log.Printf("Failed to send duel puzzle to user %d: %v", player.ID, err)
+		return false
+	}
+	puzzle.MessageID = sentMsg.MessageID
+	h.recordSeenPuzzle(player.ID, player.ID, puzzle)
+	return true
+}
+
+// handleDuelGuess checks a guess at a player's copy of the duel puzzle. The
+// first player to complete it wins the duel.
+func (h *BotHandler) handleDuelGuess(message *tgbotapi.Message, user *storage.User, duel *game.Duel) {
+	if duel.Expired(time.Now(), h.gameSvc.Config().Duel) {
+		h.expireDuel(duel)
+		return
+	}
+	puzzle := duel.Puzzles[user.ID]
+	result, ok := h.checkGuess(message, user, puzzle)
+	if !ok {
+		return
+	}
+	puzzle.Guesses++
+	puzzle.RecordMistakes(len([]rune(result.Rejected)))
+
+	if !result.IsCorrect && !result.IsPartial {
+		h.saveDuel(duel)
+		responseText := h.translator.Translate(user.LanguageCode, "wrong_answer", nil)
+		responseText += h.guessFeedback(user.LanguageCode, result)
+		h.sendMessage(message.Chat.ID, responseText, tgbotapi.ModeHTML)
+		return
+	}
+
+	puzzle.ApplyResult(result, user.ID)
+	h.editPuzzleMessage(message.Chat.ID, puzzle)
+	if puzzle.RemainingSolution == "" {
+		h.finishDuel(duel, user.ID, "duel_lost")
+		return
+	}
+	h.saveDuel(duel)
+	params := map[string]string{"guessed_chars": result.CorrectlyGuessedChars}
+	responseText := h.translator.Translate(user.LanguageCode, "partial_correct", params)
+	responseText += h.guessFeedback(user.LanguageCode, result)
+	h.sendMessage(message.Chat.ID, responseText, tgbotapi.ModeHTML)
+}
+
+// finishDuel ends an active duel won by winnerID, who gets both bets and,
+// having completed the puzzle rather than won by forfeit, its points. The
+// loser is told why with loserKey.
+func (h *BotHandler) finishDuel(duel *game.Duel, winnerID int64, loserKey string) {
+	h.mu.Lock()
+	err := duel.Finish(winnerID)
+	if err == nil {
+		delete(h.duels, duel.ID)
+	}
+	h.mu.Unlock()
+	if err != nil {
+		return
+	}
+	h.deleteDuel(duel.ID)
+
+	winner, loser := h.chatUser(winnerID), h.chatUser(duel.Opponent(winnerID))
+	winnerPuzzle, loserPuzzle := duel.Puzzles[winner.ID], duel.Puzzles[loser.ID]
+	elapsed := formatSeconds(int(time.Since(duel.StartedAt).Seconds()))
+
+	solved := winnerPuzzle.RemainingSolution == ""
+	points := 2 * duel.Bet
+	if solved {
+		points += winnerPuzzle.Reward()
+	}
+	if _, err := h.storage.IncreaseUserScore(winner.ID, points); err != nil {
+		log.Printf("Failed to pay duel %s to user %d: %v", duel.ID, winner.ID, err)
+	} else {
+		event := storage.ScoreEvent{
+			UserID: winner.ID,
+			ChatID: duel.ChatID,
+			Points: points - duel.Bet,
+			Reason: storage.ScoreReasonDuel,
+		}
+		if solved {
+			event.Solved = true
+			event.SolveSeconds = int(time.Since(duel.StartedAt).Seconds())
+		}
+		h.recordScore(event)
+	}
+	if duel.Bet > 0 {
+		h.recordScore(storage.ScoreEvent{UserID: loser.ID, ChatID: duel.ChatID, Points: -duel.Bet, Reason: storage.ScoreReasonDuel})
+	}
+	winnerParams := map[string]string{
+		"opponent": html.EscapeString(loser.FirstName),
+		"points":   strconv.Itoa(points),
+		"time":     elapsed,
+	}
+	h.sendMessage(winner.ID, h.translator.Translate(winner.LanguageCode, "duel_won", winnerParams), tgbotapi.ModeHTML)
+
+	loserPuzzle.RevealAll(0)
+	h.editPuzzleMessage(loser.ID, loserPuzzle)
+	loserParams := map[string]string{
+		"opponent": html.EscapeString(winner.FirstName),
+		"answer":   loserPuzzle.Solution,
+	}
+	h.sendMessage(loser.ID, h.translator.Translate(loser.LanguageCode, loserKey, loserParams), tgbotapi.ModeHTML)
+
+	if duel.ChatID != winner.ID && duel.ChatID != loser.ID {
+		resultParams := map[string]string{
+			"winner": html.EscapeString(winner.FirstName),
+			"loser":  html.EscapeString(loser.FirstName),
+			"time":   elapsed,
+		}
+		chatLang := h.chatUser(duel.ChatID).LanguageCode
+		h.sendMessage(duel.ChatID, h.translator.Translate(chatLang, "duel_result", resultParams), tgbotapi.ModeHTML)
+	}
+}
+
+// expireDuel ends a duel that ran out of time. An unanswered challenge gives
+// the challenger their bet back, an unsolved duel is a draw and both players
+// get their bets back.
+func (h *BotHandler) expireDuel(duel *game.Duel) {
+	h.mu.Lock()
+	wasPending := duel.State == game.DuelPending
+	err := duel.Expire()
+	if err == nil {
+		delete(h.duels, duel.ID)
+	}
+	h.mu.Unlock()
+	if err != nil {
+		return
+	}
+	h.deleteDuel(duel.ID)
+
+	if wasPending {
+		h.refundDuel(duel, duel.ChallengerID)
+		lang := h.chatUser(duel.ChatID).LanguageCode
+		h.editMessage(duel.ChatID, duel.MessageID, h.translator.Translate(lang, "duel_challenge_expired", nil), "")
+		if duel.ChatID == duel.OpponentID {
+			challenger := h.chatUser(duel.ChallengerID)
+			h.sendMessage(duel.ChallengerID, h.translator.Translate(challenger.LanguageCode, "duel_challenge_expired", nil), "")
+		}
+		return
+	}
+
+	h.refundDuel(duel, duel.ChallengerID, duel.OpponentID)
+	for playerID, puzzle := range duel.Puzzles {
+		player := h.chatUser(playerID)
+		puzzle.RevealAll(0)
+		h.editPuzzleMessage(playerID, puzzle)
+		params := map[string]string{"answer": puzzle.Solution}
+		h.sendMessage(playerID, h.translator.Translate(player.LanguageCode, "duel_draw", params), tgbotapi.ModeHTML)
+	}
+}
+
+// cancelDuel ends a duel that could not be started, gives the bets of
+// refundIDs back and tells both players.
+func (h *BotHandler) cancelDuel(duel *game.Duel, refundIDs ...int64) {
+	h.mu.Lock()
+	err := duel.Cancel()
+	if err == nil {
+		delete(h.duels, duel.ID)
+	}
+	h.mu.Unlock()
+	if err != nil {
+		return
+	}
+	h.deleteDuel(duel.ID)
+	h.refundDuel(duel, refundIDs...)
+
+	chatIDs := []int64{duel.ChallengerID, duel.OpponentID}
+	if duel.ChatID != duel.ChallengerID && duel.ChatID != duel.OpponentID {
+		chatIDs = append(chatIDs, duel.ChatID)
+	}
+	for _, chatID := range chatIDs {
+		lang := h.chatUser(chatID).LanguageCode
+		h.sendMessage(chatID, h.translator.Translate(lang, "duel_cancelled", nil), "")
+	}
+}
+
+func (h *BotHandler) refundDuel(duel *game.Duel, userIDs ...int64) {
+	if duel.Bet == 0 {
+		return
+	}
+	for _, userID := range userIDs {
+		if _, err := h.storage.IncreaseUserScore(userID, duel.Bet); err != nil {
+			log.Printf("Failed to refund duel bet to user %d: %v", userID, err)
+		}
+	}
+}
+
+// playerDuel returns the pending or active duel userID takes part in, or nil.
+func (h *BotHandler) playerDuel(userID int64) *game.Duel {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, duel := range h.duels {
+		if duel.ChallengerID == userID || duel.OpponentID == userID {
+			return duel
+		}
+	}
+	return nil
+}
+
+// activeDuel returns the duel userID is currently playing, or nil.
+func (h *BotHandler) activeDuel(userID int64) *game.Duel {
+	duel := h.playerDuel(userID)
+	if duel == nil {
+		return nil
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if duel.State != game.DuelActive {
+		return nil
+	}
+	return duel
+}
+
+// saveDuel stores the duel while it is still pending or active, so a guess
+// at a duel that just ended never writes it back.
+func (h *BotHandler) saveDuel(duel *game.Duel) {
+	h.mu.Lock()
+	_, ok := h.duels[duel.ID]
+	h.mu.Unlock()
+	if !ok {
+		return
+	}
+	if err := h.duelStore.Save(duel); err != nil {
+		log.Printf("Failed to save duel %s: %v", duel.ID, err)
+	}
+}
+
+func (h *BotHandler) deleteDuel(id string) {
+	if err := h.duelStore.Delete(id); err != nil {
+		log.Printf("Failed to delete duel %s: %v", id, err)
+	}
+}
@@ -0,0 +1,88 @@
+package bot
+
+import (
+	"strconv"
+	"strings"
+
+	"cryptowordgamebot/internal/game"
+	"cryptowordgamebot/internal/storage"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// handleEncodeGuess checks the numbers a player sent for an encoding puzzle.
+// Every letter given a wrong number costs a life.
+func (h *BotHandler) handleEncodeGuess(message *tgbotapi.Message, user *storage.User, puzzle *game.Puzzle) {
+	result, err := h.gameSvc.CheckEncoding(puzzle, message.Text)
+	if err != nil {
+		params := map[string]string{"count": strconv.Itoa(len(puzzle.HiddenSlots()))}
+		responseText := h.translator.Translate(user.LanguageCode, "encode_guess_invalid", params)
+		h.sendMessage(message.Chat.ID, responseText, tgbotapi.ModeHTML)
+		return
+	}
+	puzzle.Guesses++
+	puzzle.RecordMistakes(len([]rune(result.Rejected)))
+
+	if !result.IsCorrect && !result.IsPartial {
+		if puzzle.OutOfLives() {
+			if h.removePuzzle(message.Chat.ID, puzzle) {
+				h.failPuzzle(message.Chat.ID, puzzle, user, "out_of_lives_message")
+			}
+			return
+		}
+		h.savePuzzleState(message.Chat.ID, puzzle)
+		if result.Rejected != "" && puzzle.MaxMistakes > 0 {
+			h.editPuzzleMessage(message.Chat.ID, puzzle)
+		}
+		responseText := h.translator.Translate(user.LanguageCode, "wrong_answer", nil)
+		responseText += h.encodeFeedback(user.LanguageCode, result)
+		responseText += h.livesFeedback(user.LanguageCode, puzzle, result)
+		h.sendMessage(message.Chat.ID, responseText, tgbotapi.ModeHTML)
+		return
+	}
+
+	puzzle.ApplyResult(result, user.ID)
+	h.editPuzzleMessage(message.Chat.ID, puzzle)
+
+	if puzzle.RemainingSolution == "" {
+		if h.removePuzzle(message.Chat.ID, puzzle) {
+			h.solvePuzzle(message.Chat.ID, user, puzzle, "correct_answer")
+		}
+	} else if puzzle.OutOfLives() {
+		if h.removePuzzle(message.Chat.ID, puzzle) {
+			h.failPuzzle(message.Chat.ID, puzzle, user, "out_of_lives_message")
+		}
+	} else {
+		h.savePuzzleState(message.Chat.ID, puzzle)
+		params := map[string]string{"letters": formatLetters(result.CorrectlyGuessedChars)}
+		responseText := h.translator.Translate(user.LanguageCode, "encode_partial_correct", params)
+		responseText += h.encodeFeedback(user.LanguageCode, result)
+		responseText += h.livesFeedback(user.LanguageCode, puzzle, result)
+		h.sendMessage(message.Chat.ID, responseText, tgbotapi.ModeHTML)
+	}
+}
+
+// encodeFeedback lists the letters that got a wrong number and the letters
+// that were already found, one line each.
+func (h *BotHandler) encodeFeedback(langCode string, result *game.CheckResult) string {
+	var feedbackBuilder strings.Builder
+	if result.Rejected != "" {
+		params := map[string]string{"letters": formatLetters(result.Rejected)}
+		feedbackBuilder.WriteString("\n" + h.translator.Translate(langCode, "encode_wrong_numbers", params))
+	}
+	if result.Surplus != "" {
+		params := map[string]string{"letters": formatLetters(result.Surplus)}
+		feedbackBuilder.WriteString("\n" + h.translator.Translate(langCode, "guess_surplus_letters", params))
+	}
+	return feedbackBuilder.String()
+}
+
+// encodeRule explains how to compute the numbers of an encoding puzzle.
+func (h *BotHandler) encodeRule(languageCode string, puzzle *game.Puzzle) string {
+	explanation := h.gameSvc.Explain(puzzle)
+	params := map[string]string{
+		"shift": strconv.Itoa(explanation.Shift),
+		"size":  strconv.Itoa(explanation.AlphabetSize),
+	}
+	return h.translator.Translate(languageCode, "encode_rule_"+explanation.ValueMode, params)
+}
@@ -0,0 +1,63 @@
+package bot
+
+import (
+	"strconv"
+	"strings"
+
+	"cryptowordgamebot/internal/game"
+)
+
+const explanationTableColumns = 6
+
+// explainPuzzle builds the post-game block that reveals the cipher key, the
+// numbers of the letters in the phrase and how to decode them.
+func (h *BotHandler) explainPuzzle(languageCode string, puzzle *game.Puzzle) string {
+	explanation := h.gameSvc.Explain(puzzle)
+	params := map[string]string{
+		"shift":   strconv.Itoa(explanation.Shift),
+		"key":     explanation.Key,
+		"a":       strconv.Itoa(explanation.A),
+		"b":       strconv.Itoa(explanation.B),
+		"inverse": strconv.Itoa(explanation.Inverse),
+		"keyed":   explanation.KeyedAlphabet,
+		"size":    strconv.Itoa(explanation.AlphabetSize),
+		"mirror":  strconv.Itoa(explanation.AlphabetSize + 1),
+		"cipher":  h.translator.Translate(languageCode, "cipher_"+explanation.Cipher, nil),
+		"table":   formatLetterTable(explanation.Letters),
+	}
+
+	keyName, decodeName := "explain_key_"+explanation.Cipher, "explain_decode_"+explanation.Cipher
+	if explanation.Cipher == game.CipherCaesar {
+		keyName += "_" + explanation.ValueMode
+		decodeName += "_" + explanation.ValueMode
+	}
+
+	var b strings.Builder
+	b.WriteString("\n\n" + h.translator.Translate(languageCode, "explain_title", params))
+	b.WriteString("\n" + h.translator.Translate(languageCode, keyName, params))
+	b.WriteString("\n\n" + h.translator.Translate(languageCode, "explain_table", params))
+	b.WriteString("\n\n" + h.translator.Translate(languageCode, decodeName, params))
+	return b.String()
+}
+
+// formatLetterTable lays out the letters of the phrase as A=1 entries, a few
+// per line, in a code block so the columns line up.
+func formatLetterTable(letters []game.LetterValues) string {
+	var lines []string
+	var line []string
+	for _, letter := range letters {
+		values := make([]string, len(letter.Values))
+		for i, v := range letter.Values {
+			values[i] = strconv.Itoa(v)
+		}
+		line = append(line, string(letter.Letter)+"="+strings.Join(values, "/"))
+		if len(line) == explanationTableColumns {
+			lines = append(lines, strings.Join(line, "  "))
+			line = nil
+		}
+	}
+	if len(line) > 0 {
+		lines = append(lines, strings.Join(line, "  "))
+	}
+	return "<code>" + strings.Join(lines, "\n") + "</code>"
+}
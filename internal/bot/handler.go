@@ -1,10 +1,14 @@
 package bot
 
 import (
+	"html"
 	"log"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
+	"unicode/utf8"
 	"fmt"
 
 	"cryptowordgamebot/internal/config"
@@ -16,31 +20,59 @@ import (
 )
 
 type BotHandler struct {
-	bot           *tgbotapi.BotAPI
-	translator    *i18n.Translator
-	config        *config.Config
-	storage       *storage.Storage
-	gameSvc       *game.Service
-	themeConfig   *game.ThemeConfig 
-	powerupConfig *game.PowerupConfig
-	activePuzzles map[int64]*game.Puzzle
-	mu            sync.Mutex
-
-	
+	bot            *tgbotapi.BotAPI
+	translator     *i18n.Translator
+	config         *config.Config
+	storage        *storage.Storage
+	gameSvc        *game.Service
+	puzzleStore    *storage.PuzzleStateRepository
+	duelStore      *storage.DuelRepository
+	themeConfig    *game.ThemeConfig
+	powerupConfig  *game.PowerupConfig
+	activePuzzles  map[int64]*game.Puzzle
+	// lastDifficulty remembers the difficulty last played in each chat so the
+	// Play Again picker can offer it first.
+	lastDifficulty map[int64]string
+	// duels holds the pending and active duels by ID. Their puzzles live in
+	// the duel rather than in activePuzzles, one per player.
+	duels map[string]*game.Duel
+	// weeklyCheckedAt is when Run last looked for a week of winners to
+	// announce.
+	weeklyCheckedAt time.Time
+	mu              sync.Mutex
 }
 
-func NewBotHandler(bot *tgbotapi.BotAPI, trans *i18n.Translator, cfg *config.Config, store *storage.Storage, gameSvc *game.Service, themeCfg *game.ThemeConfig, powerupCfg *game.PowerupConfig) *BotHandler {
-	return &BotHandler{
-		bot:           bot,
-		translator:    trans,
-		config:        cfg,
-		storage:       store,
-		gameSvc:       gameSvc,
-		themeConfig:   themeCfg,
-		powerupConfig: powerupCfg,
-		activePuzzles: make(map[int64]*game.Puzzle),
-		
+func NewBotHandler(bot *tgbotapi.BotAPI, trans *i18n.Translator, cfg *config.Config, store *storage.Storage, puzzleStore *storage.PuzzleStateRepository, duelStore *storage.DuelRepository, gameSvc *game.Service, themeCfg *game.ThemeConfig, powerupCfg *game.PowerupConfig) *BotHandler {
+	activePuzzles, err := puzzleStore.LoadAll()
+	if err != nil {
+		log.Printf("Failed to restore active puzzles: %v", err)
+		activePuzzles = make(map[int64]*game.Puzzle)
+	} else {
+		log.Printf("Restored %d active puzzle(s).", len(activePuzzles))
+	}
+	duels, err := duelStore.LoadAll()
+	if err != nil {
+		log.Printf("Failed to restore duels: %v", err)
+		duels = make(map[string]*game.Duel)
+	} else {
+		log.Printf("Restored %d duel(s).", len(duels))
+	}
+
+	h := &BotHandler{
+		bot:            bot,
+		translator:     trans,
+		config:         cfg,
+		storage:        store,
+		gameSvc:        gameSvc,
+		puzzleStore:    puzzleStore,
+		duelStore:      duelStore,
+		themeConfig:    themeCfg,
+		powerupConfig:  powerupCfg,
+		activePuzzles:  activePuzzles,
+		lastDifficulty: make(map[int64]string),
+		duels:          duels,
 	}
+	return h
 }
 
 // vvv AWAL PERUBAHAN vvv
@@ -66,6 +98,13 @@ func (h *BotHandler) HandleUpdate(update tgbotapi.Update) {
 			return
 		}
 
+		if update.Message.Chat.IsPrivate() {
+			if duel := h.activeDuel(user.ID); duel != nil {
+				h.handleDuelGuess(update.Message, user, duel)
+				return
+			}
+		}
+
 		h.mu.Lock()
 		puzzle, isActive := h.activePuzzles[update.Message.Chat.ID]
 		h.mu.Unlock()
@@ -92,6 +131,14 @@ func (h *BotHandler) handleCallbackQuery(query *tgbotapi.CallbackQuery, user *st
 		h.handleMarketCallback(query, user)
 		return
 	}
+	if strings.HasPrefix(query.Data, "crypto_") {
+		h.handleDifficultyCallback(query, user)
+		return
+	}
+	if strings.HasPrefix(query.Data, "duel_") {
+		h.handleDuelCallback(query, user)
+		return
+	}
 
     var sendNewMessage bool
     var text string
@@ -103,15 +150,20 @@ func (h *BotHandler) handleCallbackQuery(query *tgbotapi.CallbackQuery, user *st
     switch query.Data {
     case "play_again":
         sendNewMessage = true
-        h.handleCryptoCommand(query.Message, user)
+        h.sendDifficultyPicker(query.Message.Chat.ID, user)
     case "help_howtoplay":
         text = h.translator.Translate(user.LanguageCode, "help_text_howtoplay", nil)
         markup = h.buildHelpKeyboard(user.LanguageCode, "back_only")
     case "help_whatiscrypto":
-        text = h.translator.Translate(user.LanguageCode, "help_text_whatiscrypto", nil)
+        params := map[string]string{"value_mode": h.valueModeHelp(query.Message.Chat.ID, user.LanguageCode)}
+        text = h.translator.Translate(user.LanguageCode, "help_text_whatiscrypto", params)
         markup = h.buildHelpKeyboard(user.LanguageCode, "back_only")
     case "help_commands":
-        text = h.translator.Translate(user.LanguageCode, "help_text_commands", nil)
+        params := map[string]string{
+            "levels": h.difficultyList(user.LanguageCode),
+            "styles": strings.Join(game.DisplayStyles, "|"),
+        }
+        text = h.translator.Translate(user.LanguageCode, "help_text_commands", params)
         markup = h.buildHelpKeyboard(user.LanguageCode, "back_only")
     case "help_main":
         fallthrough
@@ -338,49 +390,186 @@ func (h *BotHandler) handlePowerupActions(query *tgbotapi.CallbackQuery, user *s
 
 
 func (h *BotHandler) handleGuess(message *tgbotapi.Message, user *storage.User, puzzle *game.Puzzle) {
-	result := h.gameSvc.CheckAnswer(puzzle.RemainingSolution, message.Text)
+	if puzzle.Expired(time.Now()) {
+		if h.removePuzzle(message.Chat.ID, puzzle) {
+			if puzzle.Mode == game.ModeRace {
+				h.finishRace(message.Chat.ID, puzzle, user, "race_time_up")
+			} else {
+				h.failPuzzle(message.Chat.ID, puzzle, user, "time_up_message")
+			}
+		}
+		return
+	}
+
+	switch puzzle.Mode {
+	case game.ModeShift:
+		h.handleShiftGuess(message, user, puzzle)
+		return
+	case game.ModeEncode:
+		h.handleEncodeGuess(message, user, puzzle)
+		return
+	case game.ModeRace:
+		h.handleRaceGuess(message, user, puzzle)
+		return
+	}
+
+	result, ok := h.checkGuess(message, user, puzzle)
+	if !ok {
+		return
+	}
+	puzzle.Guesses++
+	puzzle.RecordMistakes(len([]rune(result.Rejected)))
 
 	if !result.IsCorrect && !result.IsPartial {
+		if puzzle.OutOfLives() {
+			if h.removePuzzle(message.Chat.ID, puzzle) {
+				h.failPuzzle(message.Chat.ID, puzzle, user, "out_of_lives_message")
+			}
+			return
+		}
+		h.savePuzzleState(message.Chat.ID, puzzle)
+		if result.Rejected != "" && puzzle.MaxMistakes > 0 {
+			h.editPuzzleMessage(message.Chat.ID, puzzle)
+		}
 		responseText := h.translator.Translate(user.LanguageCode, "wrong_answer", nil)
-		h.sendMessage(message.Chat.ID, responseText, "")
+		responseText += h.guessFeedback(user.LanguageCode, result)
+		responseText += h.livesFeedback(user.LanguageCode, puzzle, result)
+		h.sendMessage(message.Chat.ID, responseText, tgbotapi.ModeHTML)
 		return
 	}
 
-	puzzle.UpdateState(result.CorrectlyGuessedChars)
-	newPuzzleText := "`" + puzzle.RenderDisplay() + "`"
-	h.editMessage(message.Chat.ID, puzzle.MessageID, newPuzzleText, tgbotapi.ModeMarkdownV2)
+	puzzle.ApplyResult(result, user.ID)
+	h.editPuzzleMessage(message.Chat.ID, puzzle)
 
 	if puzzle.RemainingSolution == "" {
-		h.mu.Lock()
-		delete(h.activePuzzles, message.Chat.ID)
-		h.mu.Unlock()
-
-		points := puzzle.Points
-		newScore, err := h.storage.IncreaseUserScore(user.ID, points)
-		if err != nil {
-			log.Printf("Failed to increase score for user %d: %v", user.ID, err)
-			return
+		if h.removePuzzle(message.Chat.ID, puzzle) {
+			h.solvePuzzle(message.Chat.ID, user, puzzle, "correct_answer")
 		}
-		params := map[string]string{
-			"points":      strconv.Itoa(points),
-			"total_score": strconv.FormatInt(newScore, 10),
+	} else if puzzle.OutOfLives() {
+		if h.removePuzzle(message.Chat.ID, puzzle) {
+			h.failPuzzle(message.Chat.ID, puzzle, user, "out_of_lives_message")
 		}
-		responseText := h.translator.Translate(user.LanguageCode, "correct_answer", params)
-
-		playAgainButton := tgbotapi.NewInlineKeyboardButtonData(
-			h.translator.Translate(user.LanguageCode, "play_again_button", nil),
-			"play_again",
-		)
-		markup := tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(playAgainButton))
-		msg := tgbotapi.NewMessage(message.Chat.ID, responseText)
-		msg.ParseMode = tgbotapi.ModeHTML
-		msg.ReplyMarkup = markup
-		h.bot.Send(msg)
 	} else {
+		h.savePuzzleState(message.Chat.ID, puzzle)
 		params := map[string]string{"guessed_chars": result.CorrectlyGuessedChars}
 		responseText := h.translator.Translate(user.LanguageCode, "partial_correct", params)
-		h.sendMessage(message.Chat.ID, responseText, "")
+		responseText += h.guessFeedback(user.LanguageCode, result)
+		responseText += h.livesFeedback(user.LanguageCode, puzzle, result)
+		h.sendMessage(message.Chat.ID, responseText, tgbotapi.ModeHTML)
+	}
+}
+
+// checkGuess checks a letter or positional guess at a classic or race puzzle.
+// It tells the player and returns false if a positional guess is malformed.
+func (h *BotHandler) checkGuess(message *tgbotapi.Message, user *storage.User, puzzle *game.Puzzle) (*game.CheckResult, bool) {
+	if !game.IsPositionalGuess(message.Text) {
+		return h.gameSvc.CheckAnswer(puzzle, message.Text), true
 	}
+	result, err := h.gameSvc.CheckPositionalAnswer(puzzle, message.Text)
+	if err != nil {
+		params := map[string]string{"count": strconv.Itoa(len(puzzle.HiddenSlots()))}
+		responseText := h.translator.Translate(user.LanguageCode, "positional_guess_invalid", params)
+		h.sendMessage(message.Chat.ID, responseText, tgbotapi.ModeHTML)
+		return nil, false
+	}
+	return result, true
+}
+
+// solvePuzzle finishes a puzzle that user solved and that has already been
+// removed from activePuzzles: it awards the points and announces them using
+// messageKey.
+func (h *BotHandler) solvePuzzle(chatID int64, user *storage.User, puzzle *game.Puzzle, messageKey string) {
+	h.deletePuzzleState(chatID)
+
+	elapsed := time.Since(puzzle.StartedAt)
+	bonus := puzzle.SpeedBonusFor(elapsed)
+	shares := h.gameSvc.SplitReward(puzzle, puzzle.Reward()+bonus, user.ID)
+	var points int
+	var newScore int64
+	for _, share := range shares {
+		score, err := h.storage.IncreaseUserScore(share.UserID, share.Points)
+		if err != nil {
+			log.Printf("Failed to increase score for user %d: %v", share.UserID, err)
+			if share.UserID == user.ID {
+				return
+			}
+			continue
+		}
+		event := storage.ScoreEvent{UserID: share.UserID, ChatID: chatID, Points: share.Points, Reason: storage.ScoreReasonSolve}
+		if share.UserID == user.ID {
+			points, newScore = share.Points, score
+			event.Solved, event.SolveSeconds = true, int(elapsed.Seconds())
+		}
+		h.recordScore(event)
+	}
+	params := map[string]string{
+		"points":      strconv.Itoa(points),
+		"total_score": strconv.FormatInt(newScore, 10),
+		"shift":       strconv.Itoa(puzzle.Shift),
+	}
+	responseText := h.translator.Translate(user.LanguageCode, messageKey, params)
+	if puzzle.Mistakes > 0 && puzzle.MistakePenalty > 0 {
+		penaltyParams := map[string]string{
+			"mistakes": strconv.Itoa(puzzle.Mistakes),
+			"penalty":  strconv.Itoa(puzzle.Points - puzzle.Reward()),
+		}
+		responseText += "\n" + h.translator.Translate(user.LanguageCode, "mistake_penalty_note", penaltyParams)
+	}
+	if !puzzle.StartedAt.IsZero() {
+		timeParams := map[string]string{"time": formatSeconds(int(elapsed.Seconds()))}
+		responseText += "\n" + h.translator.Translate(user.LanguageCode, "solve_time_note", timeParams)
+	}
+	if bonus > 0 {
+		bonusParams := map[string]string{"bonus": strconv.Itoa(bonus)}
+		responseText += "\n" + h.translator.Translate(user.LanguageCode, "speed_bonus_note", bonusParams)
+	}
+	responseText += h.creditBreakdown(user, shares)
+	responseText += h.explainPuzzle(user.LanguageCode, puzzle)
+
+	playAgainButton := tgbotapi.NewInlineKeyboardButtonData(
+		h.translator.Translate(user.LanguageCode, "play_again_button", nil),
+		"play_again",
+	)
+	markup := tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(playAgainButton))
+	msg := tgbotapi.NewMessage(chatID, responseText)
+	msg.ParseMode = tgbotapi.ModeHTML
+	msg.ReplyMarkup = markup
+	h.bot.Send(msg)
+
+	if puzzle.DailyDate != "" {
+		h.finishDailyAttempt(chatID, user, puzzle, true)
+	}
+}
+
+func (h *BotHandler) livesFeedback(langCode string, puzzle *game.Puzzle, result *game.CheckResult) string {
+	if puzzle.MaxMistakes == 0 || result.Rejected == "" {
+		return ""
+	}
+	params := map[string]string{"lives": strconv.Itoa(puzzle.LivesLeft())}
+	return "\n" + h.translator.Translate(langCode, "lives_left", params)
+}
+
+// guessFeedback lists the rejected and surplus letters of a guess, one line
+// each, or returns an empty string when every letter was accepted.
+func (h *BotHandler) guessFeedback(langCode string, result *game.CheckResult) string {
+	var feedbackBuilder strings.Builder
+	if result.Rejected != "" {
+		params := map[string]string{"letters": formatLetters(result.Rejected)}
+		feedbackBuilder.WriteString("\n" + h.translator.Translate(langCode, "guess_rejected_letters", params))
+	}
+	if result.Surplus != "" {
+		params := map[string]string{"letters": formatLetters(result.Surplus)}
+		feedbackBuilder.WriteString("\n" + h.translator.Translate(langCode, "guess_surplus_letters", params))
+	}
+	return feedbackBuilder.String()
+}
+
+func formatLetters(letters string) string {
+	var parts []string
+	for _, r := range letters {
+		parts = append(parts, string(r))
+	}
+	return "<b>" + strings.Join(parts, ", ") + "</b>"
 }
 // ^^^ AKHIR PERUBAHAN ^^^
 
@@ -413,6 +602,10 @@ func (h *BotHandler) handleCommand(message *tgbotapi.Message, user *storage.User
 		h.handleLangCommand(message, user)
 	case "crypto":
 		h.handleCryptoCommand(message, user)
+	case "race":
+		h.handleRaceCommand(message, user)
+	case "duel":
+		h.handleDuelCommand(message, user)
 	case "score":
 		h.handleScoreCommand(message, user)
 	case "profile":
@@ -425,6 +618,10 @@ func (h *BotHandler) handleCommand(message *tgbotapi.Message, user *storage.User
 		h.handlePowerupsCommand(message, user)
 	case "surrender", "menyerah":
 		h.handleSurrenderCommand(message, user)
+	case "daily":
+		h.handleDailyCommand(message, user)
+	case "settings":
+		h.handleSettingsCommand(message, user)
 	}
 }
 
@@ -515,6 +712,11 @@ func (h *BotHandler) usePowerup(message *tgbotapi.Message, user *storage.User, p
 		h.sendMessage(message.Chat.ID, responseText, "")
 		return
 	}
+	if puzzle.Mode == game.ModeShift || puzzle.Mode == game.ModeRace {
+		responseText := h.translator.Translate(user.LanguageCode, "powerup_not_in_"+puzzle.Mode+"_mode", nil)
+		h.sendMessage(message.Chat.ID, responseText, "")
+		return
+	}
 
 	updatedUser, err := h.storage.GetUser(user.ID)
 	if err != nil {
@@ -541,13 +743,13 @@ func (h *BotHandler) usePowerup(message *tgbotapi.Message, user *storage.User, p
 	}
 
 	h.storage.UpdateUserPowerUp(user.ID, powerupID, -1)
+	h.savePuzzleState(message.Chat.ID, puzzle)
 
 	params := map[string]string{"char": string(revealedChar)}
 	responseText := h.translator.Translate(user.LanguageCode, "powerup_used_success", params)
 	h.sendMessage(message.Chat.ID, responseText, tgbotapi.ModeHTML)
 
-	newPuzzleText := "`" + puzzle.RenderDisplay() + "`"
-	h.editMessage(message.Chat.ID, puzzle.MessageID, newPuzzleText, tgbotapi.ModeMarkdownV2)
+	h.editPuzzleMessage(message.Chat.ID, puzzle)
 }
 // ▲▲▲ FUNGSI-FUNGSI BARU DITAMBAHKAN ▲▲▲
 
@@ -582,9 +784,16 @@ func (h *BotHandler) buildHelpKeyboard(langCode string, menuType string) tgbotap
 
 // vvv AWAL PERUBAHAN vvv
 func (h *BotHandler) handleSurrenderCommand(message *tgbotapi.Message, user *storage.User) {
+	if message.Chat.IsPrivate() {
+		if duel := h.activeDuel(user.ID); duel != nil {
+			h.finishDuel(duel, duel.Opponent(user.ID), "duel_forfeit")
+			return
+		}
+	}
+
 	h.mu.Lock()
 	puzzle, isActive := h.activePuzzles[message.Chat.ID]
-	if isActive {
+	if isActive && puzzle.Mode != game.ModeRace {
 		delete(h.activePuzzles, message.Chat.ID)
 	}
 	h.mu.Unlock()
@@ -594,59 +803,278 @@ func (h *BotHandler) handleSurrenderCommand(message *tgbotapi.Message, user *sto
 		h.sendMessage(message.Chat.ID, responseText, "")
 		return
 	}
+	if puzzle.Mode == game.ModeRace {
+		responseText := h.translator.Translate(user.LanguageCode, "race_no_surrender", nil)
+		h.sendMessage(message.Chat.ID, responseText, "")
+		return
+	}
+
+	h.failPuzzle(message.Chat.ID, puzzle, user, "surrender_message")
+}
+
+// failPuzzle ends a puzzle that has already been removed from activePuzzles
+// without a winner: it reveals the answer and explains why using messageKey.
+func (h *BotHandler) failPuzzle(chatID int64, puzzle *game.Puzzle, user *storage.User, messageKey string) {
+	h.deletePuzzleState(chatID)
 
-	puzzle.RevealAll()
-	finalText := "`" + puzzle.RenderDisplay() + "`"
-	h.editMessage(message.Chat.ID, puzzle.MessageID, finalText, tgbotapi.ModeMarkdownV2)
+	puzzle.RevealAll(0)
+	h.editPuzzleMessage(chatID, puzzle)
 
 	params := map[string]string{"answer": puzzle.Solution}
-	responseText := h.translator.Translate(user.LanguageCode, "surrender_message", params)
-	h.sendMessage(message.Chat.ID, responseText, tgbotapi.ModeHTML)
+	responseText := h.translator.Translate(user.LanguageCode, messageKey, params)
+	responseText += h.explainPuzzle(user.LanguageCode, puzzle)
+	h.sendMessage(chatID, responseText, tgbotapi.ModeHTML)
+
+	if puzzle.DailyDate != "" {
+		h.finishDailyAttempt(chatID, user, puzzle, false)
+	}
+}
+
+// removePuzzle removes puzzle from the active puzzles of the chat and reports
+// whether it was still there, so a puzzle is only ever finished once.
+func (h *BotHandler) removePuzzle(chatID int64, puzzle *game.Puzzle) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.activePuzzles[chatID] != puzzle {
+		return false
+	}
+	delete(h.activePuzzles, chatID)
+	return true
 }
 // ^^^ AKHIR PERUBAHAN ^^^
 
 // vvv AWAL PERUBAHAN vvv
 func (h *BotHandler) handleCryptoCommand(message *tgbotapi.Message, user *storage.User) {
-	if !message.Chat.IsPrivate() {
-		h.mu.Lock()
-		_, ok := h.activePuzzles[message.Chat.ID]
-		h.mu.Unlock()
-		if ok {
-			responseText := h.translator.Translate(user.LanguageCode, "puzzle_in_progress", nil)
-			h.sendMessage(message.Chat.ID, responseText, "")
-			return
+	args := strings.TrimSpace(message.CommandArguments())
+	mode := game.ModeClassic
+	if fields := strings.Fields(args); len(fields) > 0 {
+		switch strings.ToLower(fields[0]) {
+		case game.ModeShift, game.ModeEncode:
+			mode = strings.ToLower(fields[0])
+			args = strings.Join(fields[1:], " ")
 		}
 	}
+	if args == "" && mode == game.ModeClassic {
+		h.sendDifficultyPicker(message.Chat.ID, user)
+		return
+	}
 
-	args := strings.ToLower(strings.TrimSpace(message.CommandArguments()))
-	difficulty := "easy"
-	validDifficulties := map[string]bool{"easy": true, "medium": true, "hard": true, "veryhard": true}
+	if difficulty, ok := h.resolveDifficultyArg(message.Chat.ID, user, args); ok {
+		h.startCryptoPuzzle(message.Chat, user, difficulty, mode)
+	}
+}
 
-	if _, isValid := validDifficulties[args]; isValid && args != "" {
-		difficulty = args
+// resolveDifficultyArg resolves the difficulty named in a command, or the
+// default one if args is empty. It tells the player and returns false if no
+// level has that name.
+func (h *BotHandler) resolveDifficultyArg(chatID int64, user *storage.User, args string) (string, bool) {
+	if args == "" {
+		return h.gameSvc.Config().DefaultDifficulty, true
 	}
+	difficulty, ok := h.gameSvc.Config().ResolveDifficulty(args)
+	if !ok {
+		params := map[string]string{
+			"level":  html.EscapeString(args),
+			"levels": h.difficultyList(user.LanguageCode),
+		}
+		responseText := h.translator.Translate(user.LanguageCode, "unknown_difficulty", params)
+		h.sendMessage(chatID, responseText, tgbotapi.ModeHTML)
+	}
+	return difficulty, ok
+}
 
-	puzzle, err := h.gameSvc.GeneratePuzzle(difficulty)
+// startCryptoPuzzle generates and posts a /crypto puzzle of the given
+// difficulty and mode in chat, unless a puzzle there is still running. It
+// reports whether the puzzle was started.
+func (h *BotHandler) startCryptoPuzzle(chat *tgbotapi.Chat, user *storage.User, difficulty, mode string) bool {
+	h.mu.Lock()
+	current, ok := h.activePuzzles[chat.ID]
+	h.mu.Unlock()
+	if ok && !chat.IsPrivate() {
+		responseText := h.translator.Translate(user.LanguageCode, "puzzle_in_progress", nil)
+		h.sendMessage(chat.ID, responseText, "")
+		return false
+	}
+	if chat.IsPrivate() && h.activeDuel(user.ID) != nil {
+		responseText := h.translator.Translate(user.LanguageCode, "duel_in_progress", nil)
+		h.sendMessage(chat.ID, responseText, "")
+		return false
+	}
+	if ok && current.DailyDate != "" {
+		responseText := h.translator.Translate(user.LanguageCode, "daily_in_progress", nil)
+		h.sendMessage(chat.ID, responseText, "")
+		return false
+	}
+
+	var puzzle *game.Puzzle
+	var err error
+	switch mode {
+	case game.ModeShift:
+		puzzle, err = h.gameSvc.GenerateShiftPuzzle(difficulty, h.recentPuzzles(chat.ID, user.ID))
+	case game.ModeEncode:
+		puzzle, err = h.gameSvc.GenerateEncodePuzzle(difficulty, h.recentPuzzles(chat.ID, user.ID))
+	case game.ModeRace:
+		puzzle, err = h.gameSvc.GenerateRacePuzzle(difficulty, h.recentPuzzles(chat.ID, user.ID))
+	default:
+		puzzle, err = h.gameSvc.GeneratePuzzle(difficulty, h.recentPuzzles(chat.ID, user.ID))
+	}
 	if err != nil {
 		log.Printf("Failed to generate puzzle: %v", err)
+		return false
+	}
+	log.Printf("Generated %s puzzle %s for chat %d with seed %d", puzzle.Difficulty, puzzle.PuzzleID, chat.ID, puzzle.Seed)
+
+	if !h.startPuzzle(chat.ID, user, puzzle) {
+		return false
+	}
+	h.mu.Lock()
+	h.lastDifficulty[chat.ID] = puzzle.Difficulty
+	h.mu.Unlock()
+	h.recordSeenPuzzle(chat.ID, user.ID, puzzle)
+	return true
+}
+
+// valueModeHelp explains how shifted values behave for the puzzle running in
+// the chat or, if there is none, for the default difficulty.
+func (h *BotHandler) valueModeHelp(chatID int64, languageCode string) string {
+	valueMode := h.gameSvc.Config().ValueMode(h.gameSvc.Config().DefaultDifficulty)
+	h.mu.Lock()
+	if puzzle, ok := h.activePuzzles[chatID]; ok {
+		valueMode = puzzle.ValueMode
+	}
+	h.mu.Unlock()
+	if valueMode == game.ValueModeModular {
+		return h.translator.Translate(languageCode, "value_mode_modular", nil)
+	}
+	return h.translator.Translate(languageCode, "value_mode_linear", nil)
+}
+
+// difficultyList formats the configured difficulties in order, each with its
+// localized name, for help and error messages.
+func (h *BotHandler) difficultyList(languageCode string) string {
+	gameCfg := h.gameSvc.Config()
+	var levels []string
+	for _, key := range gameCfg.DifficultyKeys() {
+		levels = append(levels, fmt.Sprintf("<code>%s</code> (%s)", key, gameCfg.DifficultyName(key, languageCode)))
+	}
+	return strings.Join(levels, ", ")
+}
+
+const recentPuzzleLimit = 50
+
+// recentPuzzles returns the IDs of the puzzles recently seen by the user or,
+// in groups, by the chat, most recent first, so they can be avoided. A private
+// chat has the same ID as its user and is tracked as the user only.
+func (h *BotHandler) recentPuzzles(chatID, userID int64) []string {
+	scopes := []historyScope{{storage.HistoryScopeUser, userID}}
+	if chatID != userID {
+		scopes = append(scopes, historyScope{storage.HistoryScopeChat, chatID})
+	}
+	return h.mergeRecentPuzzles(scopes...)
+}
+
+// historyScope is a user or chat whose puzzle history is tracked.
+type historyScope struct {
+	scopeType string
+	id        int64
+}
+
+// mergeRecentPuzzles merges the puzzle histories of several scopes into one
+// list of IDs, most recent first.
+func (h *BotHandler) mergeRecentPuzzles(scopes ...historyScope) []string {
+	var rows []storage.SeenPuzzle
+	for _, scope := range scopes {
+		scopeRows, err := h.storage.GetRecentPuzzles(scope.scopeType, scope.id, recentPuzzleLimit)
+		if err != nil {
+			log.Printf("Failed to get recent puzzles for %s %d: %v", scope.scopeType, scope.id, err)
+		}
+		rows = append(rows, scopeRows...)
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		return rows[i].SeenAt.After(rows[j].SeenAt)
+	})
+
+	var recent []string
+	seen := make(map[string]bool)
+	for _, row := range rows {
+		if !seen[row.PuzzleID] {
+			seen[row.PuzzleID] = true
+			recent = append(recent, row.PuzzleID)
+		}
+	}
+	return recent
+}
+
+func (h *BotHandler) recordSeenPuzzle(chatID, userID int64, puzzle *game.Puzzle) {
+	if puzzle.PuzzleID == game.ProceduralPuzzleID {
 		return
 	}
+	if err := h.storage.RecordSeenPuzzle(storage.HistoryScopeUser, userID, puzzle.PuzzleID); err != nil {
+		log.Printf("Failed to record seen puzzle for user %d: %v", userID, err)
+	}
+	if chatID != userID {
+		if err := h.storage.RecordSeenPuzzle(storage.HistoryScopeChat, chatID, puzzle.PuzzleID); err != nil {
+			log.Printf("Failed to record seen puzzle for chat %d: %v", chatID, err)
+		}
+	}
+}
 
-	params := map[string]string{"count": strconv.Itoa(len(puzzle.Solution))}
-	introText := h.translator.Translate(user.LanguageCode, "new_puzzle", params)
-	h.sendMessage(message.Chat.ID, introText, "")
+func (h *BotHandler) startPuzzle(chatID int64, user *storage.User, puzzle *game.Puzzle) bool {
+	params := map[string]string{
+		"count":  strconv.Itoa(utf8.RuneCountInString(puzzle.Solution)),
+		"cipher": h.translator.Translate(user.LanguageCode, "cipher_"+puzzle.Cipher.Name, nil),
+	}
+	introKey := "new_puzzle"
+	switch puzzle.Mode {
+	case game.ModeShift:
+		introKey = "new_shift_puzzle"
+	case game.ModeEncode:
+		introKey = "new_encode_puzzle"
+		params["rule"] = h.encodeRule(user.LanguageCode, puzzle)
+	case game.ModeRace:
+		introKey = "new_race_puzzle"
+	}
+	introText := h.translator.Translate(user.LanguageCode, introKey, params)
+	if puzzle.TimeLimit > 0 {
+		limitParams := map[string]string{"time": formatSeconds(int(puzzle.TimeLimit.Seconds()))}
+		introText += "\n" + h.translator.Translate(user.LanguageCode, "time_limit_note", limitParams)
+	}
+	h.sendMessage(chatID, introText, tgbotapi.ModeHTML)
 
-	puzzleText := "`" + puzzle.RenderDisplay() + "`"
-	sentMsg, err := h.sendMessage(message.Chat.ID, puzzleText, tgbotapi.ModeMarkdownV2)
+	puzzle.Style = h.displayStyle(chatID, user)
+	sentMsg, err := h.sendPuzzleMessage(chatID, puzzle)
 	if err != nil {
 		log.Printf("Failed to send puzzle message: %v", err)
-		return
+		return false
 	}
 	puzzle.MessageID = sentMsg.MessageID
+	puzzle.StartedAt = time.Now().UTC()
 
 	h.mu.Lock()
-	h.activePuzzles[message.Chat.ID] = puzzle
+	h.activePuzzles[chatID] = puzzle
 	h.mu.Unlock()
+	h.savePuzzleState(chatID, puzzle)
+	return true
+}
+
+// savePuzzleState stores the puzzle while it is still the active puzzle of
+// the chat, so a guess at a puzzle that just ended never writes it back.
+func (h *BotHandler) savePuzzleState(chatID int64, puzzle *game.Puzzle) {
+	h.mu.Lock()
+	active := h.activePuzzles[chatID] == puzzle
+	h.mu.Unlock()
+	if !active {
+		return
+	}
+	if err := h.puzzleStore.Save(chatID, puzzle); err != nil {
+		log.Printf("Failed to save puzzle state for chat %d: %v", chatID, err)
+	}
+}
+
+func (h *BotHandler) deletePuzzleState(chatID int64) {
+	if err := h.puzzleStore.Delete(chatID); err != nil {
+		log.Printf("Failed to delete puzzle state for chat %d: %v", chatID, err)
+	}
 }
 // ^^^ AKHIR PERUBAHAN ^^^
 
@@ -732,7 +1160,21 @@ func (h *BotHandler) handleProfileCommand(message *tgbotapi.Message, user *stora
 }
 
 func (h *BotHandler) handleLeaderboardCommand(message *tgbotapi.Message, user *storage.User) {
-	topUsers, err := h.storage.GetTopUsers(10)
+	scope, period, ok := parseLeaderboardArgs(message.CommandArguments(), message.Chat.IsPrivate())
+	if !ok {
+		responseText := h.translator.Translate(user.LanguageCode, "leaderboard_usage", nil)
+		h.sendMessage(message.Chat.ID, responseText, tgbotapi.ModeHTML)
+		return
+	}
+	if scope == leaderboardGlobal && period == leaderboardAll {
+		h.sendGlobalLeaderboard(message, user)
+		return
+	}
+	h.sendScoreLeaderboard(message, user, scope, period)
+}
+
+func (h *BotHandler) sendGlobalLeaderboard(message *tgbotapi.Message, user *storage.User) {
+	topUsers, err := h.storage.GetTopUsers(leaderboardSize)
 	if err != nil {
 		log.Printf("Failed to get top users for leaderboard: %v", err)
 		return
@@ -0,0 +1,132 @@
+package bot
+
+import (
+	"html"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"cryptowordgamebot/internal/storage"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Scopes and periods of /leaderboard. Without a scope, groups see their own
+// leaderboard and private chats the global one; without a period it covers
+// all time. Weeks start on Monday and, like months, follow UTC.
+const (
+	leaderboardGroup  = "group"
+	leaderboardGlobal = "global"
+
+	leaderboardWeek  = "week"
+	leaderboardMonth = "month"
+	leaderboardAll   = "all"
+)
+
+const leaderboardSize = 10
+
+// parseLeaderboardArgs reads the optional scope and period of /leaderboard,
+// given in any order.
+func parseLeaderboardArgs(args string, isPrivate bool) (scope, period string, ok bool) {
+	scope, period = leaderboardGlobal, leaderboardAll
+	if !isPrivate {
+		scope = leaderboardGroup
+	}
+	for _, arg := range strings.Fields(strings.ToLower(args)) {
+		switch arg {
+		case leaderboardGroup, leaderboardGlobal:
+			scope = arg
+		case leaderboardWeek, leaderboardMonth, leaderboardAll:
+			period = arg
+		default:
+			return "", "", false
+		}
+	}
+	return scope, period, true
+}
+
+// periodStart returns when the leaderboard period containing now began, or
+// the zero time for all time.
+func periodStart(period string, now time.Time) time.Time {
+	now = now.UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	switch period {
+	case leaderboardWeek:
+		return today.AddDate(0, 0, -(int(today.Weekday())+6)%7)
+	case leaderboardMonth:
+		return today.AddDate(0, 0, 1-today.Day())
+	}
+	return time.Time{}
+}
+
+// sendScoreLeaderboard shows the players with the most points earned in the
+// group, or in every chat, during a period. The all-time group leaderboard is
+// followed by the group's statistics.
+func (h *BotHandler) sendScoreLeaderboard(message *tgbotapi.Message, user *storage.User, scope, period string) {
+	var chatID int64
+	if scope == leaderboardGroup {
+		if message.Chat.IsPrivate() {
+			responseText := h.translator.Translate(user.LanguageCode, "leaderboard_group_only", nil)
+			h.sendMessage(message.Chat.ID, responseText, "")
+			return
+		}
+		chatID = message.Chat.ID
+	}
+	entries, err := h.storage.GetLeaderboard(chatID, periodStart(period, time.Now()), time.Time{}, leaderboardSize)
+	if err != nil {
+		log.Printf("Failed to get %s leaderboard for chat %d: %v", period, chatID, err)
+		return
+	}
+
+	var leaderboardBuilder strings.Builder
+	if scope == leaderboardGroup {
+		titleParams := map[string]string{"group": html.EscapeString(message.Chat.Title)}
+		leaderboardBuilder.WriteString(h.translator.Translate(user.LanguageCode, "leaderboard_group_title", titleParams))
+	} else {
+		leaderboardBuilder.WriteString(h.translator.Translate(user.LanguageCode, "leaderboard_title", nil))
+	}
+	if period != leaderboardAll {
+		leaderboardBuilder.WriteString(h.translator.Translate(user.LanguageCode, "leaderboard_period_"+period, nil))
+	}
+	if len(entries) == 0 {
+		leaderboardBuilder.WriteString(h.translator.Translate(user.LanguageCode, "leaderboard_empty", nil))
+	}
+	for i, entry := range entries {
+		params := map[string]string{
+			"rank":  strconv.Itoa(i + 1),
+			"name":  html.EscapeString(entry.FirstName),
+			"score": strconv.FormatInt(entry.Points, 10),
+		}
+		leaderboardBuilder.WriteString(h.translator.Translate(user.LanguageCode, "leaderboard_entry", params))
+	}
+	if scope == leaderboardGroup && period == leaderboardAll {
+		stats, err := h.storage.GetChatStats(chatID)
+		if err != nil {
+			log.Printf("Failed to get stats for chat %d: %v", chatID, err)
+		} else {
+			leaderboardBuilder.WriteString(h.groupStats(user.LanguageCode, stats))
+		}
+	}
+	h.sendMessage(message.Chat.ID, leaderboardBuilder.String(), tgbotapi.ModeHTML)
+}
+
+func (h *BotHandler) groupStats(langCode string, stats *storage.ChatStats) string {
+	if stats.PuzzlesSolved == 0 {
+		return ""
+	}
+	params := map[string]string{
+		"solved":       strconv.Itoa(stats.PuzzlesSolved),
+		"average_time": formatSeconds(stats.AverageSolveSeconds),
+		"top_solver":   html.EscapeString(stats.TopSolverName),
+		"top_puzzles":  strconv.Itoa(stats.TopSolverPuzzles),
+	}
+	return "\n" + h.translator.Translate(langCode, "group_stats", params)
+}
+
+// recordScore logs points earned in a chat for the group leaderboards.
+func (h *BotHandler) recordScore(event storage.ScoreEvent) {
+	if err := h.storage.RecordScoreEvent(event); err != nil {
+		log.Printf("Failed to record score event for user %d in chat %d: %v", event.UserID, event.ChatID, err)
+	}
+}
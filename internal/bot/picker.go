@@ -0,0 +1,62 @@
+package bot
+
+import (
+	"log"
+	"strconv"
+	"strings"
+
+	"cryptowordgamebot/internal/game"
+	"cryptowordgamebot/internal/storage"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// sendDifficultyPicker replies with one button per configured difficulty. The
+// difficulty last played in the chat, if any, is offered first.
+func (h *BotHandler) sendDifficultyPicker(chatID int64, user *storage.User) {
+	gameCfg := h.gameSvc.Config()
+
+	h.mu.Lock()
+	last := h.lastDifficulty[chatID]
+	h.mu.Unlock()
+
+	var keyboardRows [][]tgbotapi.InlineKeyboardButton
+	if _, ok := gameCfg.Difficulties[last]; ok {
+		params := map[string]string{"level": gameCfg.DifficultyName(last, user.LanguageCode)}
+		buttonText := h.translator.Translate(user.LanguageCode, "difficulty_button_again", params)
+		keyboardRows = append(keyboardRows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(buttonText, "crypto_"+last)))
+	}
+	for _, key := range gameCfg.DifficultyKeys() {
+		level := gameCfg.Difficulties[key]
+		params := map[string]string{
+			"level":  gameCfg.DifficultyName(key, user.LanguageCode),
+			"points": strconv.Itoa(level.Points),
+			"hidden": strconv.Itoa(level.HidePercentage),
+		}
+		buttonText := h.translator.Translate(user.LanguageCode, "difficulty_button", params)
+		keyboardRows = append(keyboardRows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(buttonText, "crypto_"+key)))
+	}
+
+	msg := tgbotapi.NewMessage(chatID, h.translator.Translate(user.LanguageCode, "difficulty_picker", nil))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(keyboardRows...)
+	if _, err := h.bot.Send(msg); err != nil {
+		log.Printf("Failed to send difficulty picker: %v", err)
+	}
+}
+
+// handleDifficultyCallback starts the puzzle picked from the difficulty
+// picker and removes the picker buttons once it has started.
+func (h *BotHandler) handleDifficultyCallback(query *tgbotapi.CallbackQuery, user *storage.User) {
+	h.bot.Request(tgbotapi.NewCallback(query.ID, ""))
+
+	difficulty := strings.TrimPrefix(query.Data, "crypto_")
+	if _, ok := h.gameSvc.Config().Difficulties[difficulty]; !ok {
+		log.Printf("Ignoring unknown difficulty from picker: %s", difficulty)
+		return
+	}
+
+	if h.startCryptoPuzzle(query.Message.Chat, user, difficulty, game.ModeClassic) {
+		emptyMarkup := tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}}
+		h.bot.Request(tgbotapi.NewEditMessageReplyMarkup(query.Message.Chat.ID, query.Message.MessageID, emptyMarkup))
+	}
+}
@@ -0,0 +1,117 @@
+package bot
+
+import (
+	"html"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"cryptowordgamebot/internal/game"
+	"cryptowordgamebot/internal/storage"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+var podiumMedals = []string{"🥇", "🥈", "🥉"}
+
+// handleRaceCommand starts a race puzzle in a group, /race [level].
+func (h *BotHandler) handleRaceCommand(message *tgbotapi.Message, user *storage.User) {
+	if message.Chat.IsPrivate() {
+		responseText := h.translator.Translate(user.LanguageCode, "race_group_only", nil)
+		h.sendMessage(message.Chat.ID, responseText, "")
+		return
+	}
+	args := strings.TrimSpace(message.CommandArguments())
+	if difficulty, ok := h.resolveDifficultyArg(message.Chat.ID, user, args); ok {
+		h.startCryptoPuzzle(message.Chat, user, difficulty, game.ModeRace)
+	}
+}
+
+// handleRaceGuess credits the letters of a race guess to the player who sent
+// it. Wrong letters are taken off the player's own tally instead of costing
+// shared lives.
+func (h *BotHandler) handleRaceGuess(message *tgbotapi.Message, user *storage.User, puzzle *game.Puzzle) {
+	result, ok := h.checkGuess(message, user, puzzle)
+	if !ok {
+		return
+	}
+	puzzle.Guesses++
+	puzzle.RecordPlayerMistakes(user.ID, len([]rune(result.Rejected)))
+
+	responseKey := "wrong_answer"
+	var params map[string]string
+	if result.IsCorrect || result.IsPartial {
+		puzzle.ApplyResult(result, user.ID)
+		h.editPuzzleMessage(message.Chat.ID, puzzle)
+		responseKey = "race_letters"
+		params = map[string]string{"letters": formatLetters(result.CorrectlyGuessedChars)}
+	}
+
+	if puzzle.RemainingSolution == "" {
+		if h.removePuzzle(message.Chat.ID, puzzle) {
+			h.finishRace(message.Chat.ID, puzzle, user, "race_complete")
+		}
+		return
+	}
+	h.savePuzzleState(message.Chat.ID, puzzle)
+
+	responseText := h.translator.Translate(user.LanguageCode, responseKey, params)
+	responseText += h.guessFeedback(user.LanguageCode, result)
+	tallyParams := map[string]string{
+		"letters":  strconv.Itoa(puzzle.PlayerLetters(user.ID)),
+		"mistakes": strconv.Itoa(puzzle.PlayerMistakes[user.ID]),
+	}
+	responseText += "\n" + h.translator.Translate(user.LanguageCode, "race_tally", tallyParams)
+	h.sendMessage(message.Chat.ID, responseText, tgbotapi.ModeHTML)
+}
+
+// finishRace ends a race that has already been removed from activePuzzles:
+// it reveals the answer, pays every player and announces the podium, using
+// messageKey to say why the race ended.
+func (h *BotHandler) finishRace(chatID int64, puzzle *game.Puzzle, user *storage.User, messageKey string) {
+	h.deletePuzzleState(chatID)
+
+	puzzle.RevealAll(0)
+	h.editPuzzleMessage(chatID, puzzle)
+
+	params := map[string]string{"answer": puzzle.Solution}
+	responseText := h.translator.Translate(user.LanguageCode, messageKey, params)
+
+	standings := h.gameSvc.RaceStandings(puzzle)
+	if len(standings) == 0 {
+		responseText += "\n\n" + h.translator.Translate(user.LanguageCode, "race_no_players", nil)
+	} else {
+		responseText += "\n\n" + h.translator.Translate(user.LanguageCode, "race_podium_title", nil)
+	}
+	completed := puzzle.RemainingSolution == ""
+	for i, standing := range standings {
+		event := storage.ScoreEvent{UserID: standing.UserID, ChatID: chatID, Points: standing.Points, Reason: storage.ScoreReasonRace}
+		if completed && standing.UserID == user.ID {
+			event.Solved, event.SolveSeconds = true, int(time.Since(puzzle.StartedAt).Seconds())
+		}
+		if standing.Points > 0 {
+			if _, err := h.storage.IncreaseUserScore(standing.UserID, standing.Points); err != nil {
+				log.Printf("Failed to increase score for user %d: %v", standing.UserID, err)
+			} else {
+				h.recordScore(event)
+			}
+		} else if event.Solved {
+			h.recordScore(event)
+		}
+		rank := strconv.Itoa(i+1) + "."
+		if i < len(podiumMedals) {
+			rank = podiumMedals[i]
+		}
+		standingParams := map[string]string{
+			"rank":     rank,
+			"name":     html.EscapeString(h.playerName(standing.UserID)),
+			"letters":  strconv.Itoa(standing.Letters),
+			"mistakes": strconv.Itoa(standing.Mistakes),
+			"points":   strconv.Itoa(standing.Points),
+		}
+		responseText += "\n" + h.translator.Translate(user.LanguageCode, "race_standing", standingParams)
+	}
+	responseText += h.explainPuzzle(user.LanguageCode, puzzle)
+	h.sendMessage(chatID, responseText, tgbotapi.ModeHTML)
+}
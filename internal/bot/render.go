@@ -0,0 +1,86 @@
+package bot
+
+import (
+	"log"
+
+	"cryptowordgamebot/internal/game"
+	"cryptowordgamebot/internal/storage"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// chatPuzzleFormat returns the format puzzles are sent in for a chat, which
+// is an image card unless the chat switched to text.
+func (h *BotHandler) chatPuzzleFormat(chatID int64) string {
+	settings, err := h.storage.GetChatSettings(chatID)
+	if err != nil {
+		log.Printf("Failed to get settings for chat %d: %v", chatID, err)
+		return game.FormatImage
+	}
+	if settings.PuzzleFormat == game.FormatText {
+		return game.FormatText
+	}
+	return game.FormatImage
+}
+
+// displayStyle returns the text display style for puzzles in a chat: the
+// user's own choice in a private chat and the group's choice in a group.
+func (h *BotHandler) displayStyle(chatID int64, user *storage.User) string {
+	if chatID == user.ID {
+		return user.DisplayStyle
+	}
+	settings, err := h.storage.GetChatSettings(chatID)
+	if err != nil {
+		log.Printf("Failed to get settings for chat %d: %v", chatID, err)
+		return game.StyleSuperscript
+	}
+	return settings.DisplayStyle
+}
+
+// sendPuzzleMessage posts the puzzle in the chat's format and records the
+// format on the puzzle so later edits use the same one. If the image card
+// cannot be sent, or its font lacks letters of the puzzle, the puzzle falls
+// back to text.
+func (h *BotHandler) sendPuzzleMessage(chatID int64, puzzle *game.Puzzle) (tgbotapi.Message, error) {
+	if h.chatPuzzleFormat(chatID) == game.FormatImage && puzzle.CanRenderImage() {
+		sentMsg, err := h.sendPuzzleImage(chatID, puzzle)
+		if err == nil {
+			puzzle.Format = game.FormatImage
+			return sentMsg, nil
+		}
+		log.Printf("Failed to send puzzle image to chat %d, falling back to text: %v", chatID, err)
+	}
+
+	puzzle.Format = game.FormatText
+	return h.sendMessage(chatID, "`"+puzzle.RenderStyle(puzzle.Style)+"`", tgbotapi.ModeMarkdownV2)
+}
+
+func (h *BotHandler) sendPuzzleImage(chatID int64, puzzle *game.Puzzle) (tgbotapi.Message, error) {
+	card, err := puzzle.RenderImage()
+	if err != nil {
+		return tgbotapi.Message{}, err
+	}
+	return h.bot.Send(tgbotapi.NewPhoto(chatID, tgbotapi.FileBytes{Name: "puzzle.png", Bytes: card}))
+}
+
+// editPuzzleMessage redraws the puzzle message after its state changed,
+// replacing the photo of image puzzles through edit-media.
+func (h *BotHandler) editPuzzleMessage(chatID int64, puzzle *game.Puzzle) {
+	if puzzle.Format != game.FormatImage {
+		h.editMessage(chatID, puzzle.MessageID, "`"+puzzle.RenderStyle(puzzle.Style)+"`", tgbotapi.ModeMarkdownV2)
+		return
+	}
+
+	card, err := puzzle.RenderImage()
+	if err != nil {
+		log.Printf("Failed to render puzzle image for chat %d: %v", chatID, err)
+		return
+	}
+	edit := tgbotapi.EditMessageMediaConfig{
+		BaseEdit: tgbotapi.BaseEdit{ChatID: chatID, MessageID: puzzle.MessageID},
+		Media:    tgbotapi.NewInputMediaPhoto(tgbotapi.FileBytes{Name: "puzzle.png", Bytes: card}),
+	}
+	if _, err := h.bot.Request(edit); err != nil {
+		log.Printf("Failed to update puzzle image for chat %d: %v", chatID, err)
+	}
+}
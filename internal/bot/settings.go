@@ -0,0 +1,138 @@
+package bot
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"cryptowordgamebot/internal/game"
+	"cryptowordgamebot/internal/storage"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Values of on/off settings.
+const (
+	settingOn  = "on"
+	settingOff = "off"
+)
+
+// handleSettingsCommand shows or changes the settings of the chat. In a
+// private chat they belong to the user; in groups they belong to the group
+// and only administrators may change them.
+func (h *BotHandler) handleSettingsCommand(message *tgbotapi.Message, user *storage.User) {
+	args := strings.Fields(strings.ToLower(message.CommandArguments()))
+	if len(args) == 0 {
+		style := h.displayStyle(message.Chat.ID, user)
+		if style == "" {
+			style = game.StyleSuperscript
+		}
+		params := map[string]string{
+			"format": h.chatPuzzleFormat(message.Chat.ID),
+			"style":  style,
+			"styles": strings.Join(game.DisplayStyles, "|"),
+		}
+		responseText := h.translator.Translate(user.LanguageCode, "settings_overview", params)
+		if !message.Chat.IsPrivate() {
+			weeklyParams := map[string]string{"state": h.weeklyState(user.LanguageCode, message.Chat.ID)}
+			responseText += h.translator.Translate(user.LanguageCode, "settings_overview_weekly", weeklyParams)
+		}
+		h.sendMessage(message.Chat.ID, responseText, tgbotapi.ModeHTML)
+		return
+	}
+
+	if !message.Chat.IsPrivate() && !h.isChatAdmin(message.Chat.ID, user.ID) {
+		responseText := h.translator.Translate(user.LanguageCode, "settings_admin_only", nil)
+		h.sendMessage(message.Chat.ID, responseText, "")
+		return
+	}
+
+	switch {
+	case args[0] == "format" && len(args) == 2 && (args[1] == game.FormatImage || args[1] == game.FormatText):
+		if err := h.storage.UpdateChatPuzzleFormat(message.Chat.ID, args[1]); err != nil {
+			log.Printf("Failed to update puzzle format for chat %d: %v", message.Chat.ID, err)
+			return
+		}
+		params := map[string]string{"format": args[1]}
+		responseText := h.translator.Translate(user.LanguageCode, "settings_format_updated", params)
+		h.sendMessage(message.Chat.ID, responseText, tgbotapi.ModeHTML)
+	case args[0] == "display" && len(args) == 2 && isDisplayStyle(args[1]):
+		var err error
+		if message.Chat.IsPrivate() {
+			err = h.storage.UpdateUserDisplayStyle(user.ID, args[1])
+		} else {
+			err = h.storage.UpdateChatDisplayStyle(message.Chat.ID, args[1])
+		}
+		if err != nil {
+			log.Printf("Failed to update display style for chat %d: %v", message.Chat.ID, err)
+			return
+		}
+		params := map[string]string{"style": args[1]}
+		responseText := h.translator.Translate(user.LanguageCode, "settings_display_updated", params)
+		h.sendMessage(message.Chat.ID, responseText, tgbotapi.ModeHTML)
+	case args[0] == "weekly" && len(args) == 2 && (args[1] == settingOn || args[1] == settingOff):
+		h.updateWeeklyAnnouncement(message, user, args[1] == settingOn)
+	default:
+		params := map[string]string{"styles": strings.Join(game.DisplayStyles, "|")}
+		responseText := h.translator.Translate(user.LanguageCode, "settings_usage", params)
+		h.sendMessage(message.Chat.ID, responseText, tgbotapi.ModeHTML)
+	}
+}
+
+// updateWeeklyAnnouncement opts a group in or out of the announcement of last
+// week's winners. A group that opts in hears about the first week that ends
+// after it did, not the one before.
+func (h *BotHandler) updateWeeklyAnnouncement(message *tgbotapi.Message, user *storage.User, enabled bool) {
+	if message.Chat.IsPrivate() {
+		responseText := h.translator.Translate(user.LanguageCode, "settings_weekly_group_only", nil)
+		h.sendMessage(message.Chat.ID, responseText, "")
+		return
+	}
+	if enabled {
+		lastWeek := periodStart(leaderboardWeek, time.Now()).AddDate(0, 0, -7)
+		if err := h.storage.MarkWeeklyAnnouncement(message.Chat.ID, game.DailyKey(lastWeek)); err != nil {
+			log.Printf("Failed to mark the weekly announcement for chat %d: %v", message.Chat.ID, err)
+			return
+		}
+	}
+	if err := h.storage.UpdateChatWeeklyAnnouncement(message.Chat.ID, enabled); err != nil {
+		log.Printf("Failed to update the weekly announcement for chat %d: %v", message.Chat.ID, err)
+		return
+	}
+	params := map[string]string{"state": h.weeklyState(user.LanguageCode, message.Chat.ID)}
+	responseText := h.translator.Translate(user.LanguageCode, "settings_weekly_updated", params)
+	h.sendMessage(message.Chat.ID, responseText, tgbotapi.ModeHTML)
+}
+
+// weeklyState describes whether a group gets the weekly winners announcement.
+func (h *BotHandler) weeklyState(lang string, chatID int64) string {
+	settings, err := h.storage.GetChatSettings(chatID)
+	if err != nil {
+		log.Printf("Failed to get settings for chat %d: %v", chatID, err)
+		return h.translator.Translate(lang, "setting_off", nil)
+	}
+	if settings.WeeklyAnnouncement {
+		return h.translator.Translate(lang, "setting_on", nil)
+	}
+	return h.translator.Translate(lang, "setting_off", nil)
+}
+
+func isDisplayStyle(style string) bool {
+	for _, s := range game.DisplayStyles {
+		if s == style {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *BotHandler) isChatAdmin(chatID, userID int64) bool {
+	member, err := h.bot.GetChatMember(tgbotapi.GetChatMemberConfig{
+		ChatConfigWithUser: tgbotapi.ChatConfigWithUser{ChatID: chatID, UserID: userID},
+	})
+	if err != nil {
+		log.Printf("Failed to get chat member %d in chat %d: %v", userID, chatID, err)
+		return false
+	}
+	return member.IsCreator() || member.IsAdministrator()
+}
@@ -0,0 +1,53 @@
+package bot
+
+import (
+	"html"
+	"strconv"
+
+	"cryptowordgamebot/internal/game"
+	"cryptowordgamebot/internal/storage"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// handleShiftGuess checks a guess at the shift of a shift-recovery puzzle.
+// Every wrong shift costs a life; the right one reveals the whole phrase.
+func (h *BotHandler) handleShiftGuess(message *tgbotapi.Message, user *storage.User, puzzle *game.Puzzle) {
+	correct, err := h.gameSvc.CheckShift(puzzle, message.Text)
+	if err != nil {
+		responseText := h.translator.Translate(user.LanguageCode, "shift_guess_invalid", nil)
+		h.sendMessage(message.Chat.ID, responseText, tgbotapi.ModeHTML)
+		return
+	}
+	puzzle.Guesses++
+
+	if correct {
+		puzzle.RevealAll(user.ID)
+		puzzle.RemainingSolution = ""
+		h.editPuzzleMessage(message.Chat.ID, puzzle)
+		if h.removePuzzle(message.Chat.ID, puzzle) {
+			h.solvePuzzle(message.Chat.ID, user, puzzle, "shift_correct")
+		}
+		return
+	}
+
+	puzzle.RecordMistakes(1)
+	if puzzle.OutOfLives() {
+		if h.removePuzzle(message.Chat.ID, puzzle) {
+			h.failPuzzle(message.Chat.ID, puzzle, user, "out_of_lives_message")
+		}
+		return
+	}
+	h.savePuzzleState(message.Chat.ID, puzzle)
+	if puzzle.MaxMistakes > 0 {
+		h.editPuzzleMessage(message.Chat.ID, puzzle)
+	}
+
+	params := map[string]string{"shift": html.EscapeString(message.Text)}
+	responseText := h.translator.Translate(user.LanguageCode, "shift_wrong", params)
+	if puzzle.MaxMistakes > 0 {
+		livesParams := map[string]string{"lives": strconv.Itoa(puzzle.LivesLeft())}
+		responseText += "\n" + h.translator.Translate(user.LanguageCode, "lives_left", livesParams)
+	}
+	h.sendMessage(message.Chat.ID, responseText, tgbotapi.ModeHTML)
+}
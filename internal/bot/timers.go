@@ -0,0 +1,106 @@
+package bot
+
+import (
+	"time"
+
+	"cryptowordgamebot/internal/game"
+	"cryptowordgamebot/internal/storage"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const timerCheckInterval = 5 * time.Second
+
+// Run handles updates until the channel is closed. Puzzle and duel timers
+// are checked on the same goroutine, so nothing expires while a guess is
+// changing it.
+func (h *BotHandler) Run(updates tgbotapi.UpdatesChannel) {
+	ticker := time.NewTicker(timerCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case update, ok := <-updates:
+			if !ok {
+				return
+			}
+			h.HandleUpdate(update)
+		case now := <-ticker.C:
+			h.expirePuzzles(now)
+			h.announceCountdowns(now)
+			h.expireDuels(now)
+			h.checkWeeklyAnnouncements(now)
+		}
+	}
+}
+
+// expirePuzzles ends puzzles whose time limit has run out, even when nobody
+// sends another message to the chat.
+func (h *BotHandler) expirePuzzles(now time.Time) {
+	expired := make(map[int64]*game.Puzzle)
+	h.mu.Lock()
+	for chatID, puzzle := range h.activePuzzles {
+		if puzzle.Expired(now) {
+			expired[chatID] = puzzle
+			delete(h.activePuzzles, chatID)
+		}
+	}
+	h.mu.Unlock()
+
+	for chatID, puzzle := range expired {
+		if puzzle.Mode == game.ModeRace {
+			h.finishRace(chatID, puzzle, h.chatUser(chatID), "race_time_up")
+		} else {
+			h.failPuzzle(chatID, puzzle, h.chatUser(chatID), "time_up_message")
+		}
+	}
+}
+
+// announceCountdowns tells every race chat how much time is left when the
+// race reaches one of its countdown steps.
+func (h *BotHandler) announceCountdowns(now time.Time) {
+	races := make(map[int64]*game.Puzzle)
+	h.mu.Lock()
+	for chatID, puzzle := range h.activePuzzles {
+		if puzzle.Mode == game.ModeRace {
+			races[chatID] = puzzle
+		}
+	}
+	h.mu.Unlock()
+
+	for chatID, puzzle := range races {
+		left, due := puzzle.NextCountdown(now, h.gameSvc.Config().Race.Countdown)
+		if !due {
+			continue
+		}
+		h.savePuzzleState(chatID, puzzle)
+		params := map[string]string{"time": formatSeconds(int(left.Round(time.Second).Seconds()))}
+		responseText := h.translator.Translate(h.chatUser(chatID).LanguageCode, "race_countdown", params)
+		h.sendMessage(chatID, responseText, tgbotapi.ModeHTML)
+	}
+}
+
+// expireDuels ends duels whose challenge or time limit ran out.
+func (h *BotHandler) expireDuels(now time.Time) {
+	var expired []*game.Duel
+	h.mu.Lock()
+	for _, duel := range h.duels {
+		if duel.Expired(now, h.gameSvc.Config().Duel) {
+			expired = append(expired, duel)
+		}
+	}
+	h.mu.Unlock()
+
+	for _, duel := range expired {
+		h.expireDuel(duel)
+	}
+}
+
+// chatUser returns the user whose private chat has the given ID, or a
+// placeholder with the default language for group chats.
+func (h *BotHandler) chatUser(chatID int64) *storage.User {
+	if user, err := h.storage.GetUser(chatID); err == nil {
+		return user
+	}
+	return &storage.User{ID: chatID, LanguageCode: h.config.DefaultLanguage}
+}
@@ -0,0 +1,74 @@
+package bot
+
+import (
+	"html"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"cryptowordgamebot/internal/game"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const weeklyCheckInterval = time.Hour
+
+// weeklyWinners is how many of last week's best players are announced.
+const weeklyWinners = 3
+
+// checkWeeklyAnnouncements announces last week's winners in every group that
+// opted in, once per week, shortly after the week is over. It is called on
+// every timer tick but only looks for unannounced weeks once an interval.
+func (h *BotHandler) checkWeeklyAnnouncements(now time.Time) {
+	if now.Sub(h.weeklyCheckedAt) < weeklyCheckInterval {
+		return
+	}
+	h.weeklyCheckedAt = now
+	h.announceWeeklyWinners(now)
+}
+
+func (h *BotHandler) announceWeeklyWinners(now time.Time) {
+	thisWeek := periodStart(leaderboardWeek, now)
+	lastWeek := thisWeek.AddDate(0, 0, -7)
+	week := game.DailyKey(lastWeek)
+
+	chats, err := h.storage.GetWeeklyAnnouncementChats()
+	if err != nil {
+		log.Printf("Failed to get chats for the weekly announcement: %v", err)
+		return
+	}
+	for _, chat := range chats {
+		if chat.LastWeeklyAnnouncement == week {
+			continue
+		}
+		entries, err := h.storage.GetLeaderboard(chat.ChatID, lastWeek, thisWeek, weeklyWinners)
+		if err != nil {
+			log.Printf("Failed to get last week's leaderboard for chat %d: %v", chat.ChatID, err)
+			continue
+		}
+		if len(entries) > 0 {
+			lang := h.chatUser(chat.ChatID).LanguageCode
+			var announcementBuilder strings.Builder
+			titleParams := map[string]string{
+				"from": game.DailyKey(lastWeek),
+				"to":   game.DailyKey(thisWeek.AddDate(0, 0, -1)),
+			}
+			announcementBuilder.WriteString(h.translator.Translate(lang, "weekly_winners_title", titleParams))
+			for i, entry := range entries {
+				params := map[string]string{
+					"rank":  podiumMedals[i],
+					"name":  html.EscapeString(entry.FirstName),
+					"score": strconv.FormatInt(entry.Points, 10),
+				}
+				announcementBuilder.WriteString("\n" + h.translator.Translate(lang, "weekly_winners_entry", params))
+			}
+			if _, err := h.sendMessage(chat.ChatID, announcementBuilder.String(), tgbotapi.ModeHTML); err != nil {
+				continue
+			}
+		}
+		if err := h.storage.MarkWeeklyAnnouncement(chat.ChatID, week); err != nil {
+			log.Printf("Failed to mark the weekly announcement for chat %d: %v", chat.ChatID, err)
+		}
+	}
+}
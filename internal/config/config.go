@@ -3,6 +3,7 @@ package config
 import (
 	"errors"
 	"os"
+	"strconv"
 
 	"github.com/joho/godotenv"
 )
@@ -12,6 +13,7 @@ type Config struct {
 	SupabaseURL      string
 	SupabaseKey      string
 	DefaultLanguage  string
+	GameSeed         int64
 }
 
 func New() (*Config, error) {
@@ -24,10 +26,20 @@ func New() (*Config, error) {
 		return nil, errors.New("TELEGRAM_BOT_TOKEN is not set in .env file")
 	}
 
+	var gameSeed int64
+	if seed := os.Getenv("GAME_SEED"); seed != "" {
+		parsed, err := strconv.ParseInt(seed, 10, 64)
+		if err != nil {
+			return nil, errors.New("GAME_SEED must be an integer")
+		}
+		gameSeed = parsed
+	}
+
 	return &Config{
 		TelegramBotToken: token,
 		SupabaseURL:      os.Getenv("SUPABASE_URL"),
 		SupabaseKey:      os.Getenv("SUPABASE_KEY"),
 		DefaultLanguage:  os.Getenv("DEFAULT_LANGUAGE"),
+		GameSeed:         gameSeed,
 	}, nil
 }
\ No newline at end of file
@@ -0,0 +1,127 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DefaultAlphabet is the plain Latin alphabet used for languages that have
+// no alphabet of their own in game.yaml.
+const DefaultAlphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+// Alphabet defines the letters of a puzzle language. Letters are numbered in
+// the order they are listed, Normalize maps letters that are written but not
+// part of the alphabet, such as É, to their plain form, and Symbols are shown
+// in the puzzle as they are instead of being encoded.
+type Alphabet struct {
+	Letters   string            `yaml:"letters"`
+	Normalize map[string]string `yaml:"normalize"`
+	Symbols   string            `yaml:"symbols"`
+
+	letters   []rune
+	index     map[rune]int
+	normalize map[rune]string
+}
+
+// init builds the lookup tables of the alphabet after it has been decoded.
+func (a *Alphabet) init() error {
+	if a.Letters == "" {
+		a.Letters = DefaultAlphabet
+	}
+	a.letters = []rune(strings.ToUpper(a.Letters))
+	a.index = make(map[rune]int, len(a.letters))
+	for i, r := range a.letters {
+		if _, ok := a.index[r]; ok {
+			return fmt.Errorf("letter %q is listed twice", r)
+		}
+		a.index[r] = i
+	}
+
+	a.normalize = make(map[rune]string, len(a.Normalize))
+	for from, to := range a.Normalize {
+		r, size := utf8.DecodeRuneInString(strings.ToUpper(from))
+		if size != len(strings.ToUpper(from)) {
+			return fmt.Errorf("normalize key %q must be a single character", from)
+		}
+		to = strings.ToUpper(to)
+		for _, t := range to {
+			if _, ok := a.index[t]; !ok {
+				return fmt.Errorf("normalize target %q is not in the alphabet", to)
+			}
+		}
+		a.normalize[r] = to
+	}
+
+	for _, r := range a.Symbols {
+		if _, ok := a.index[r]; ok {
+			return fmt.Errorf("symbol %q is also a letter", r)
+		}
+		if r == '`' || r == '\\' {
+			return fmt.Errorf("symbol %q cannot be shown in a puzzle", r)
+		}
+	}
+	return nil
+}
+
+func newDefaultAlphabet() *Alphabet {
+	a := &Alphabet{}
+	a.init()
+	return a
+}
+
+// Size returns the number of letters in the alphabet.
+func (a *Alphabet) Size() int {
+	return len(a.letters)
+}
+
+// Index returns the 0-based position of letter r in the alphabet.
+func (a *Alphabet) Index(r rune) (int, bool) {
+	i, ok := a.index[r]
+	return i, ok
+}
+
+// Letter returns the letter at 0-based position i of the alphabet.
+func (a *Alphabet) Letter(i int) rune {
+	return a.letters[i]
+}
+
+// IsSymbol reports whether r is shown in the puzzle as it is.
+func (a *Alphabet) IsSymbol(r rune) bool {
+	return strings.ContainsRune(a.Symbols, r)
+}
+
+// NormalizeText upper-cases text and replaces every letter with a normalized
+// form, so that for example "café" becomes "CAFE".
+func (a *Alphabet) NormalizeText(text string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(text) {
+		if to, ok := a.normalize[r]; ok {
+			b.WriteString(to)
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// CheckText reports an error if the normalized text contains a letter that is
+// not in the alphabet and so cannot be encoded.
+func (a *Alphabet) CheckText(text string) error {
+	for _, r := range a.NormalizeText(text) {
+		if _, ok := a.index[r]; !ok && unicode.IsLetter(r) {
+			return fmt.Errorf("letter %q is not in the alphabet", r)
+		}
+	}
+	return nil
+}
+
+// Alphabet returns the alphabet of a puzzle language, or the default Latin
+// alphabet if the language has none configured.
+func (c *Config) Alphabet(language string) *Alphabet {
+	if alphabet, ok := c.Alphabets[language]; ok {
+		return alphabet
+	}
+	return c.defaultAlphabet
+}
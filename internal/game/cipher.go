@@ -0,0 +1,195 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	CipherCaesar   = "caesar"
+	CipherAtbash   = "atbash"
+	CipherAffine   = "affine"
+	CipherKeyword  = "keyword"
+	CipherVigenere = "vigenere"
+)
+
+// Value modes decide what happens when a Caesar shift runs past the end of the
+// alphabet. In the legacy linear mode the values keep counting (Z with shift
+// 10 is 36); in modular mode they wrap around (Z with shift 10 is 10).
+const (
+	ValueModeLinear  = "linear"
+	ValueModeModular = "modular"
+)
+
+// Cipher turns the alphabet index of a plaintext letter into the number shown
+// as its clue. pos is the position of the letter among the letters of the
+// phrase, which keyed ciphers such as Vigenère use to pick the key letter.
+type Cipher interface {
+	Name() string
+	Encode(index, pos int) int
+}
+
+// CipherConfig names the cipher of a puzzle and its parameters. In game.yaml it
+// can be written either as a plain name (`cipher: atbash`) or as a mapping
+// (`cipher: {name: affine, a: 5, b: 8}`). An empty name means Caesar.
+type CipherConfig struct {
+	Name string `yaml:"name" json:"name,omitempty"`
+	Key  string `yaml:"key" json:"key,omitempty"`
+	A    int    `yaml:"a" json:"a,omitempty"`
+	B    int    `yaml:"b" json:"b,omitempty"`
+}
+
+func (c *CipherConfig) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		c.Name = value.Value
+		return nil
+	}
+	type plain CipherConfig
+	return value.Decode((*plain)(c))
+}
+
+// NewCipher builds the cipher described by cfg over the letters of alphabet.
+// valueMode only changes the Caesar cipher; the other ciphers always wrap
+// around the alphabet.
+func NewCipher(cfg CipherConfig, shift int, valueMode string, alphabet *Alphabet) (Cipher, error) {
+	size := alphabet.Size()
+	switch strings.ToLower(cfg.Name) {
+	case "", CipherCaesar:
+		switch valueMode {
+		case "", ValueModeLinear:
+			return caesarCipher{shift: shift}, nil
+		case ValueModeModular:
+			return caesarCipher{shift: shift, size: size}, nil
+		default:
+			return nil, fmt.Errorf("unknown value mode: %s", valueMode)
+		}
+	case CipherAtbash:
+		return atbashCipher{size: size}, nil
+	case CipherAffine:
+		if gcd(cfg.A, size) != 1 {
+			return nil, fmt.Errorf("affine multiplier %d must be coprime with %d", cfg.A, size)
+		}
+		return affineCipher{a: cfg.A, b: cfg.B, size: size}, nil
+	case CipherKeyword:
+		key, err := keyIndices(cfg.Key, alphabet)
+		if err != nil {
+			return nil, err
+		}
+		return newKeywordCipher(key, size), nil
+	case CipherVigenere:
+		key, err := keyIndices(cfg.Key, alphabet)
+		if err != nil {
+			return nil, err
+		}
+		return vigenereCipher{key: key, size: size}, nil
+	default:
+		return nil, fmt.Errorf("unknown cipher: %s", cfg.Name)
+	}
+}
+
+// caesarCipher wraps around an alphabet of size letters, or counts on past
+// the end of the alphabet when size is 0.
+type caesarCipher struct {
+	shift int
+	size  int
+}
+
+func (c caesarCipher) Name() string { return CipherCaesar }
+
+func (c caesarCipher) Encode(index, pos int) int {
+	if c.size > 0 {
+		return mod(index+c.shift, c.size) + 1
+	}
+	return index + 1 + c.shift
+}
+
+type atbashCipher struct {
+	size int
+}
+
+func (atbashCipher) Name() string { return CipherAtbash }
+
+func (c atbashCipher) Encode(index, pos int) int {
+	return c.size - index
+}
+
+type affineCipher struct {
+	a, b, size int
+}
+
+func (c affineCipher) Name() string { return CipherAffine }
+
+func (c affineCipher) Encode(index, pos int) int {
+	return mod(c.a*index+c.b, c.size) + 1
+}
+
+type keywordCipher struct {
+	mapping []int
+}
+
+// newKeywordCipher builds the substitution alphabet by writing the keyword
+// without repeated letters, followed by the rest of the alphabet in order.
+func newKeywordCipher(key []int, size int) keywordCipher {
+	used := make([]bool, size)
+	mapping := make([]int, 0, size)
+	for _, k := range key {
+		if !used[k] {
+			used[k] = true
+			mapping = append(mapping, k)
+		}
+	}
+	for i := 0; i < size; i++ {
+		if !used[i] {
+			mapping = append(mapping, i)
+		}
+	}
+	return keywordCipher{mapping: mapping}
+}
+
+func (c keywordCipher) Name() string { return CipherKeyword }
+
+func (c keywordCipher) Encode(index, pos int) int {
+	return c.mapping[index] + 1
+}
+
+type vigenereCipher struct {
+	key  []int
+	size int
+}
+
+func (c vigenereCipher) Name() string { return CipherVigenere }
+
+func (c vigenereCipher) Encode(index, pos int) int {
+	return mod(index+c.key[pos%len(c.key)], c.size) + 1
+}
+
+func keyIndices(key string, alphabet *Alphabet) ([]int, error) {
+	var indices []int
+	for _, r := range alphabet.NormalizeText(key) {
+		index, ok := alphabet.Index(r)
+		if !ok {
+			return nil, fmt.Errorf("cipher key %q may only contain letters of the alphabet", key)
+		}
+		indices = append(indices, index)
+	}
+	if len(indices) == 0 {
+		return nil, fmt.Errorf("cipher key is required")
+	}
+	return indices, nil
+}
+
+func mod(a, n int) int {
+	return ((a % n) + n) % n
+}
+
+func gcd(a, b int) int {
+	if a < 0 {
+		a = -a
+	}
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
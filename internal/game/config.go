@@ -3,23 +3,70 @@ package game
 import (
 	"fmt"
 	"os"
+	"sort"
+	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
 type PuzzleConfig struct {
-	Text  string      `yaml:"text"`
-	Shift interface{} `yaml:"shift"`
+	ID       string       `yaml:"id"`
+	Text     string       `yaml:"text"`
+	Language string       `yaml:"language"`
+	Shift    interface{}  `yaml:"shift"`
+	Cipher   CipherConfig `yaml:"cipher"`
+	// ValueMode overrides the value mode of the puzzle's difficulty level.
+	ValueMode string `yaml:"value_mode"`
 }
 
+// DifficultyLevel describes one level of /crypto. Order sorts the levels in
+// lists and menus, Names holds the display name per language code, and
+// Aliases are extra words players may type instead of the level key.
 type DifficultyLevel struct {
-	Points         int            `yaml:"points"`
-	HidePercentage int            `yaml:"hide_percentage"`
-	Puzzles        []PuzzleConfig `yaml:"puzzles"`
+	Order          int               `yaml:"order"`
+	Names          map[string]string `yaml:"names"`
+	Aliases        []string          `yaml:"aliases"`
+	Points         int               `yaml:"points"`
+	HidePercentage int               `yaml:"hide_percentage"`
+	ValueMode      string            `yaml:"value_mode"`
+	MaxMistakes    int               `yaml:"max_mistakes"`
+	MistakePenalty int               `yaml:"mistake_penalty"`
+	TimeLimit      time.Duration     `yaml:"time_limit"`
+	SpeedBonus     []SpeedBonus      `yaml:"speed_bonus"`
+	Procedural     *ProceduralConfig `yaml:"procedural"`
+	Puzzles        []PuzzleConfig    `yaml:"puzzles"`
+}
+
+// SpeedBonus awards Bonus extra points when a puzzle is solved Within the
+// given time of being posted.
+type SpeedBonus struct {
+	Within time.Duration `yaml:"within" json:"within"`
+	Bonus  int           `yaml:"bonus" json:"bonus"`
+}
+
+type DailyConfig struct {
+	Difficulty string `yaml:"difficulty"`
 }
 
 type Config struct {
-	Difficulties map[string]DifficultyLevel `yaml:"difficulties"`
+	Difficulties      map[string]DifficultyLevel `yaml:"difficulties"`
+	DefaultDifficulty string                     `yaml:"default_difficulty"`
+	// Language is the language of curated puzzles that do not name one.
+	Language  string               `yaml:"language"`
+	Alphabets map[string]*Alphabet `yaml:"alphabets"`
+	Daily     DailyConfig          `yaml:"daily"`
+	// FinisherBonus is the percentage of a group puzzle's reward that goes to
+	// the player who completes it before the rest is split by letters guessed.
+	FinisherBonus int         `yaml:"finisher_bonus"`
+	Race          RaceConfig  `yaml:"race"`
+	Duel          DuelConfig  `yaml:"duel"`
+	DictionaryDir string      `yaml:"dictionary_dir"`
+	Dictionary    *Dictionary `yaml:"-"`
+
+	difficultyOrder []string
+	aliases         map[string]string
+	defaultAlphabet *Alphabet
 }
 
 func LoadConfig(filePath string) (*Config, error) {
@@ -38,5 +85,158 @@ func LoadConfig(filePath string) (*Config, error) {
 		return nil, fmt.Errorf("no difficulties found in game config")
 	}
 
+	config.defaultAlphabet = newDefaultAlphabet()
+	for language, alphabet := range config.Alphabets {
+		if alphabet == nil {
+			alphabet = &Alphabet{}
+			config.Alphabets[language] = alphabet
+		}
+		if err := alphabet.init(); err != nil {
+			return nil, fmt.Errorf("invalid %s alphabet: %w", language, err)
+		}
+	}
+
+	if config.DictionaryDir != "" {
+		dictionary, err := LoadDictionary(config.DictionaryDir)
+		if err != nil {
+			return nil, err
+		}
+		config.Dictionary = dictionary
+	}
+
+	config.aliases = make(map[string]string)
+	for name, level := range config.Difficulties {
+		config.difficultyOrder = append(config.difficultyOrder, name)
+		for _, alias := range append([]string{name}, level.Aliases...) {
+			alias = strings.ToLower(strings.TrimSpace(alias))
+			if other, ok := config.aliases[alias]; ok && other != name {
+				return nil, fmt.Errorf("difficulty alias %q is used by both %s and %s", alias, other, name)
+			}
+			config.aliases[alias] = name
+		}
+	}
+	sort.Slice(config.difficultyOrder, func(i, j int) bool {
+		a, b := config.difficultyOrder[i], config.difficultyOrder[j]
+		if config.Difficulties[a].Order != config.Difficulties[b].Order {
+			return config.Difficulties[a].Order < config.Difficulties[b].Order
+		}
+		return a < b
+	})
+
+	if config.DefaultDifficulty == "" {
+		config.DefaultDifficulty = config.difficultyOrder[0]
+	}
+	if _, ok := config.Difficulties[config.DefaultDifficulty]; !ok {
+		return nil, fmt.Errorf("default difficulty %q not found in game config", config.DefaultDifficulty)
+	}
+	if config.FinisherBonus < 0 || config.FinisherBonus > 100 {
+		return nil, fmt.Errorf("finisher bonus %d must be between 0 and 100", config.FinisherBonus)
+	}
+	if config.Race.TimeLimit <= 0 {
+		config.Race.TimeLimit = defaultRaceTimeLimit
+	}
+	sort.Slice(config.Race.Countdown, func(i, j int) bool {
+		return config.Race.Countdown[i] > config.Race.Countdown[j]
+	})
+	if config.Duel.AcceptTimeout <= 0 {
+		config.Duel.AcceptTimeout = defaultDuelAcceptTimeout
+	}
+	if config.Duel.TimeLimit <= 0 {
+		config.Duel.TimeLimit = defaultDuelTimeLimit
+	}
+	if config.Daily.Difficulty != "" {
+		if _, ok := config.Difficulties[config.Daily.Difficulty]; !ok {
+			return nil, fmt.Errorf("daily difficulty %q not found in game config", config.Daily.Difficulty)
+		}
+	}
+
+	puzzleIDs := make(map[string]bool)
+	for name, level := range config.Difficulties {
+		if _, err := NewCipher(CipherConfig{}, 0, level.ValueMode, config.defaultAlphabet); err != nil {
+			return nil, fmt.Errorf("invalid %s difficulty: %w", name, err)
+		}
+		if level.Procedural != nil {
+			words := config.Dictionary.Words(level.Procedural.Language, level.Procedural.Categories)
+			if len(words) == 0 {
+				return nil, fmt.Errorf("no dictionary words found for procedural %s puzzles", name)
+			}
+			alphabet := config.Alphabet(level.Procedural.Language)
+			for _, word := range words {
+				if err := alphabet.CheckText(word); err != nil {
+					return nil, fmt.Errorf("invalid dictionary word %q for procedural %s puzzles: %w", word, name, err)
+				}
+			}
+		}
+		sort.Slice(level.SpeedBonus, func(i, j int) bool {
+			return level.SpeedBonus[i].Within < level.SpeedBonus[j].Within
+		})
+		for i := range level.Puzzles {
+			puzzle := &level.Puzzles[i]
+			if puzzle.ID == "" {
+				puzzle.ID = name + "-" + strings.ReplaceAll(strings.ToLower(strings.TrimSpace(puzzle.Text)), " ", "-")
+			}
+			if puzzle.ID == ProceduralPuzzleID || puzzleIDs[puzzle.ID] {
+				return nil, fmt.Errorf("duplicate puzzle id %q in %s puzzles", puzzle.ID, name)
+			}
+			puzzleIDs[puzzle.ID] = true
+			alphabet := config.Alphabet(config.puzzleLanguage(*puzzle))
+			if err := alphabet.CheckText(puzzle.Text); err != nil {
+				return nil, fmt.Errorf("invalid text for %s puzzle %q: %w", name, puzzle.Text, err)
+			}
+			if _, err := NewCipher(puzzle.Cipher, 0, puzzleValueMode(level, *puzzle), alphabet); err != nil {
+				return nil, fmt.Errorf("invalid cipher for %s puzzle %q: %w", name, puzzle.Text, err)
+			}
+		}
+	}
+
 	return &config, nil
-}
\ No newline at end of file
+}
+
+// DifficultyKeys returns the difficulty keys sorted by their configured order.
+func (c *Config) DifficultyKeys() []string {
+	return c.difficultyOrder
+}
+
+// ResolveDifficulty maps what a player typed, either a difficulty key or one
+// of its aliases, to the difficulty key.
+func (c *Config) ResolveDifficulty(input string) (string, bool) {
+	key, ok := c.aliases[strings.ToLower(strings.TrimSpace(input))]
+	return key, ok
+}
+
+// DifficultyName returns the display name of a difficulty in the given
+// language, falling back to English and then to the key itself.
+func (c *Config) DifficultyName(key, languageCode string) string {
+	names := c.Difficulties[key].Names
+	if name, ok := names[languageCode]; ok {
+		return name
+	}
+	if name, ok := names["en"]; ok {
+		return name
+	}
+	return key
+}
+
+func (c *Config) puzzleLanguage(puzzle PuzzleConfig) string {
+	if puzzle.Language != "" {
+		return puzzle.Language
+	}
+	return c.Language
+}
+
+// ValueMode returns the value mode of the puzzles of a difficulty level.
+func (c *Config) ValueMode(difficulty string) string {
+	return puzzleValueMode(c.Difficulties[difficulty], PuzzleConfig{})
+}
+
+// puzzleValueMode returns the value mode of a puzzle, which defaults to the
+// value mode of its difficulty level.
+func puzzleValueMode(level DifficultyLevel, puzzle PuzzleConfig) string {
+	if puzzle.ValueMode != "" {
+		return puzzle.ValueMode
+	}
+	if level.ValueMode != "" {
+		return level.ValueMode
+	}
+	return ValueModeLinear
+}
@@ -0,0 +1,76 @@
+package game
+
+import "sort"
+
+// Share is the part of a puzzle's reward that goes to one player.
+type Share struct {
+	UserID int64
+	// Letters is the number of hidden letters the player filled in.
+	Letters  int
+	Points   int
+	Finisher bool
+}
+
+// SplitReward divides points between the players who filled in the letters of
+// puzzle. The finisher first gets the configured finisher bonus, then the rest
+// is split in proportion to the letters each player guessed, with the points
+// left over by rounding going to the largest contributions. Letters revealed
+// by power-ups count for nobody. Shares are ordered by points, highest first.
+func (s *Service) SplitReward(puzzle *Puzzle, points int, finisherID int64) []Share {
+	var order []int64
+	letters := make(map[int64]int)
+	total := 0
+	for _, pc := range puzzle.Chars {
+		if !pc.IsGuessed || pc.GuessedBy == 0 {
+			continue
+		}
+		if _, ok := letters[pc.GuessedBy]; !ok {
+			order = append(order, pc.GuessedBy)
+		}
+		letters[pc.GuessedBy]++
+		total++
+	}
+	if _, ok := letters[finisherID]; !ok {
+		order = append(order, finisherID)
+	}
+	if total == 0 {
+		return []Share{{UserID: finisherID, Points: points, Finisher: true}}
+	}
+
+	bonus := points * s.config.FinisherBonus / 100
+	rest := points - bonus
+	shares := make([]Share, len(order))
+	remainders := make([]int, len(order))
+	left := rest
+	for i, userID := range order {
+		shares[i] = Share{
+			UserID:   userID,
+			Letters:  letters[userID],
+			Points:   rest * letters[userID] / total,
+			Finisher: userID == finisherID,
+		}
+		remainders[i] = rest * letters[userID] % total
+		left -= shares[i].Points
+	}
+
+	byRemainder := make([]int, len(order))
+	for i := range byRemainder {
+		byRemainder[i] = i
+	}
+	sort.SliceStable(byRemainder, func(a, b int) bool {
+		return remainders[byRemainder[a]] > remainders[byRemainder[b]]
+	})
+	for _, i := range byRemainder[:left] {
+		shares[i].Points++
+	}
+
+	for i := range shares {
+		if shares[i].Finisher {
+			shares[i].Points += bonus
+		}
+	}
+	sort.SliceStable(shares, func(a, b int) bool {
+		return shares[a].Points > shares[b].Points
+	})
+	return shares
+}
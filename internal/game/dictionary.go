@@ -0,0 +1,98 @@
+package game
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Dictionary holds the word lists used for procedural puzzles, grouped by
+// language and category. On disk every language is a directory and every
+// category a text file with one word per line, e.g. words/id/hewan.txt.
+type Dictionary struct {
+	words map[string]map[string][]string
+}
+
+func LoadDictionary(dir string) (*Dictionary, error) {
+	languages, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("could not read dictionary directory: %w", err)
+	}
+
+	d := &Dictionary{words: make(map[string]map[string][]string)}
+	for _, language := range languages {
+		if !language.IsDir() {
+			continue
+		}
+		files, err := os.ReadDir(filepath.Join(dir, language.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("could not read dictionary language %s: %w", language.Name(), err)
+		}
+		for _, file := range files {
+			if file.IsDir() || !strings.HasSuffix(file.Name(), ".txt") {
+				continue
+			}
+			category := strings.TrimSuffix(file.Name(), ".txt")
+			words, err := readWordList(filepath.Join(dir, language.Name(), file.Name()))
+			if err != nil {
+				return nil, err
+			}
+			if d.words[language.Name()] == nil {
+				d.words[language.Name()] = make(map[string][]string)
+			}
+			d.words[language.Name()][category] = words
+		}
+	}
+	return d, nil
+}
+
+func readWordList(filePath string) ([]string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("could not open word list %s: %w", filePath, err)
+	}
+	defer file.Close()
+
+	var words []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		word := strings.ToUpper(strings.TrimSpace(scanner.Text()))
+		if word == "" || strings.HasPrefix(word, "#") {
+			continue
+		}
+		if strings.IndexFunc(word, func(r rune) bool { return !unicode.IsLetter(r) }) >= 0 {
+			continue
+		}
+		words = append(words, word)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not read word list %s: %w", filePath, err)
+	}
+	return words, nil
+}
+
+// Words returns the words of the given categories of a language, or of all its
+// categories when none are given. The result is sorted so that puzzles drawn
+// from it can be reproduced from their seed.
+func (d *Dictionary) Words(language string, categories []string) []string {
+	if d == nil {
+		return nil
+	}
+	byCategory := d.words[language]
+	if len(categories) == 0 {
+		for category := range byCategory {
+			categories = append(categories, category)
+		}
+	}
+
+	var words []string
+	for _, category := range categories {
+		words = append(words, byCategory[category]...)
+	}
+	sort.Strings(words)
+	return words
+}
@@ -0,0 +1,154 @@
+package game
+
+import (
+	"fmt"
+	"time"
+)
+
+// ModeDuel is the mode of the puzzles of a duel: both players get their own
+// copy of the same puzzle and the first to complete it wins. Duel puzzles use
+// the duel time limit and have no lives.
+const ModeDuel = "duel"
+
+const (
+	defaultDuelAcceptTimeout = 2 * time.Minute
+	defaultDuelTimeLimit     = 5 * time.Minute
+)
+
+// DuelConfig sets the rules of /duel. AcceptTimeout is how long a challenge
+// waits for an answer, TimeLimit how long the players have to solve the
+// puzzle and MaxBet the most points that can be bet, 0 for no limit.
+type DuelConfig struct {
+	AcceptTimeout time.Duration `yaml:"accept_timeout"`
+	TimeLimit     time.Duration `yaml:"time_limit"`
+	MaxBet        int           `yaml:"max_bet"`
+}
+
+// DuelState is the stage of a duel. A duel starts pending, becomes active
+// when it is accepted and ends finished, declined, expired or cancelled.
+type DuelState string
+
+const (
+	DuelPending   DuelState = "pending"
+	DuelActive    DuelState = "active"
+	DuelFinished  DuelState = "finished"
+	DuelDeclined  DuelState = "declined"
+	DuelExpired   DuelState = "expired"
+	DuelCancelled DuelState = "cancelled"
+)
+
+// Duel is a challenge between two players. ChatID and MessageID locate the
+// challenge message with its accept and decline buttons. Once the duel is
+// active, Puzzles holds the copy of the puzzle of each player by user ID.
+type Duel struct {
+	ID           string            `json:"id"`
+	ChallengerID int64             `json:"challenger_id"`
+	OpponentID   int64             `json:"opponent_id"`
+	ChatID       int64             `json:"chat_id"`
+	MessageID    int               `json:"message_id"`
+	Difficulty   string            `json:"difficulty"`
+	Bet          int               `json:"bet"`
+	State        DuelState         `json:"state"`
+	WinnerID     int64             `json:"winner_id,omitempty"`
+	CreatedAt    time.Time         `json:"created_at"`
+	StartedAt    time.Time         `json:"started_at,omitempty"`
+	Puzzles      map[int64]*Puzzle `json:"puzzles,omitempty"`
+}
+
+// NewDuel creates a pending duel in which challengerID challenges opponentID
+// from chatID.
+func NewDuel(challengerID, opponentID, chatID int64, difficulty string, bet int, now time.Time) *Duel {
+	return &Duel{
+		ID:           fmt.Sprintf("%d-%d", challengerID, now.UnixNano()),
+		ChallengerID: challengerID,
+		OpponentID:   opponentID,
+		ChatID:       chatID,
+		Difficulty:   difficulty,
+		Bet:          bet,
+		State:        DuelPending,
+		CreatedAt:    now,
+	}
+}
+
+// Opponent returns the other player of the duel.
+func (d *Duel) Opponent(userID int64) int64 {
+	if userID == d.ChallengerID {
+		return d.OpponentID
+	}
+	return d.ChallengerID
+}
+
+// StartDuel accepts a pending duel and gives both players a copy of the same
+// puzzle, generated once and rebuilt from its seed for the second player.
+func (s *Service) StartDuel(d *Duel, recent []string, now time.Time) error {
+	if d.State != DuelPending {
+		return fmt.Errorf("cannot start a duel that is %s", d.State)
+	}
+	puzzle, err := s.newPuzzle(d.Difficulty, ModeDuel, recent)
+	if err != nil {
+		return err
+	}
+	twin, err := s.RegeneratePuzzle(puzzle.Difficulty, puzzle.PuzzleID, ModeDuel, puzzle.Seed)
+	if err != nil {
+		return err
+	}
+	puzzle.StartedAt, twin.StartedAt = now, now
+
+	d.Difficulty = puzzle.Difficulty
+	d.Puzzles = map[int64]*Puzzle{d.ChallengerID: puzzle, d.OpponentID: twin}
+	d.State = DuelActive
+	d.StartedAt = now
+	return nil
+}
+
+// Decline ends a pending duel without playing it.
+func (d *Duel) Decline() error {
+	if d.State != DuelPending {
+		return fmt.Errorf("cannot decline a duel that is %s", d.State)
+	}
+	d.State = DuelDeclined
+	return nil
+}
+
+// Finish ends an active duel with winnerID as the winner. It fails if the duel
+// was already decided, so only the first player to finish wins.
+func (d *Duel) Finish(winnerID int64) error {
+	if d.State != DuelActive {
+		return fmt.Errorf("cannot finish a duel that is %s", d.State)
+	}
+	d.State = DuelFinished
+	d.WinnerID = winnerID
+	return nil
+}
+
+// Expire ends a duel that was not accepted or not solved in time, see
+// Expired.
+func (d *Duel) Expire() error {
+	if d.State != DuelPending && d.State != DuelActive {
+		return fmt.Errorf("cannot expire a duel that is %s", d.State)
+	}
+	d.State = DuelExpired
+	return nil
+}
+
+// Cancel ends a duel that cannot go on, for example because the puzzle could
+// not be sent to one of the players.
+func (d *Duel) Cancel() error {
+	if d.State != DuelPending && d.State != DuelActive {
+		return fmt.Errorf("cannot cancel a duel that is %s", d.State)
+	}
+	d.State = DuelCancelled
+	return nil
+}
+
+// Expired reports whether a pending duel was not accepted within the accept
+// timeout or an active duel was not solved within the time limit by now.
+func (d *Duel) Expired(now time.Time, cfg DuelConfig) bool {
+	switch d.State {
+	case DuelPending:
+		return now.Sub(d.CreatedAt) >= cfg.AcceptTimeout
+	case DuelActive:
+		return now.Sub(d.StartedAt) >= cfg.TimeLimit
+	}
+	return false
+}
@@ -0,0 +1,88 @@
+package game
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ModeEncode is the reverse mode: the letters are shown together with the
+// shift and the players have to work out the number of every letter.
+const ModeEncode = "encode"
+
+// GenerateEncodePuzzle creates an encoding puzzle of the given difficulty,
+// see ModeEncode.
+func (s *Service) GenerateEncodePuzzle(difficulty string, recent []string) (*Puzzle, error) {
+	return s.newPuzzle(difficulty, ModeEncode, recent)
+}
+
+// CheckEncoding checks the numbers a player gave for the letters of an
+// encoding puzzle. The guess is either the numbers of every letter in order,
+// such as "12 15 22 5", or positional, such as "3=22" or "2,4=15,5", where
+// the letters are numbered from 1 in phrase order. Letters with a wrong
+// number end up in Rejected and letters already found in Surplus.
+func (s *Service) CheckEncoding(puzzle *Puzzle, guess string) (*CheckResult, error) {
+	slots := puzzle.HiddenSlots()
+
+	var positions, values []int
+	var err error
+	if IsPositionalGuess(guess) {
+		parts := strings.SplitN(guess, "=", 2)
+		if positions, err = parseNumbers(parts[0]); err != nil {
+			return nil, err
+		}
+		if values, err = parseNumbers(parts[1]); err != nil {
+			return nil, err
+		}
+		if len(positions) == 0 || len(positions) != len(values) {
+			return nil, fmt.Errorf("got %d position(s) for %d number(s)", len(positions), len(values))
+		}
+		for _, pos := range positions {
+			if pos < 1 || pos > len(slots) {
+				return nil, fmt.Errorf("position %d is out of range 1-%d", pos, len(slots))
+			}
+		}
+	} else {
+		if values, err = parseNumbers(guess); err != nil {
+			return nil, err
+		}
+		if len(values) != len(slots) {
+			return nil, fmt.Errorf("got %d number(s) for %d letter(s)", len(values), len(slots))
+		}
+		for i := range values {
+			positions = append(positions, i+1)
+		}
+	}
+
+	var credited []int
+	var rejected, surplus strings.Builder
+	for i, pos := range positions {
+		pc := puzzle.Chars[slots[pos-1]]
+		switch {
+		case pc.IsGuessed:
+			surplus.WriteRune(pc.Char)
+		case pc.Value != values[i]:
+			rejected.WriteRune(pc.Char)
+		default:
+			credited = append(credited, slots[pos-1])
+		}
+	}
+
+	result := slotResult(puzzle, credited)
+	result.Rejected = rejected.String()
+	result.Surplus = surplus.String()
+	return result, nil
+}
+
+func parseNumbers(text string) ([]int, error) {
+	var numbers []int
+	for _, field := range strings.FieldsFunc(text, func(r rune) bool { return r == ',' || r == '-' || unicode.IsSpace(r) }) {
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", field)
+		}
+		numbers = append(numbers, n)
+	}
+	return numbers, nil
+}
@@ -0,0 +1,103 @@
+package game
+
+import (
+	"sort"
+	"strings"
+)
+
+// Explanation describes how a puzzle was encoded so it can be shown to the
+// players once the game is over.
+type Explanation struct {
+	Cipher    string
+	ValueMode string
+	Shift     int
+	Key       string
+	A, B      int
+	// Inverse is the multiplicative inverse of A, used to decode the affine
+	// cipher.
+	Inverse int
+	// KeyedAlphabet is the substitution alphabet of the keyword cipher.
+	KeyedAlphabet string
+	AlphabetSize  int
+	Letters       []LetterValues
+}
+
+// LetterValues lists the numbers a letter of the phrase was encoded as. Only
+// keyed ciphers such as Vigenère give a letter more than one value.
+type LetterValues struct {
+	Letter rune
+	Values []int
+}
+
+func (s *Service) Explain(p *Puzzle) *Explanation {
+	alphabet := s.config.Alphabet(p.Language)
+	explanation := &Explanation{
+		Cipher:       p.Cipher.Name,
+		ValueMode:    p.ValueMode,
+		Shift:        p.Shift,
+		Key:          strings.ToUpper(p.Cipher.Key),
+		A:            p.Cipher.A,
+		B:            p.Cipher.B,
+		AlphabetSize: alphabet.Size(),
+		Letters:      p.LetterValues(),
+	}
+	if explanation.Cipher == "" {
+		explanation.Cipher = CipherCaesar
+	}
+	if explanation.ValueMode == "" {
+		explanation.ValueMode = ValueModeLinear
+	}
+
+	switch explanation.Cipher {
+	case CipherAffine:
+		explanation.Inverse = modInverse(p.Cipher.A, alphabet.Size())
+	case CipherKeyword:
+		if key, err := keyIndices(p.Cipher.Key, alphabet); err == nil {
+			var keyed strings.Builder
+			for _, index := range newKeywordCipher(key, alphabet.Size()).mapping {
+				keyed.WriteRune(alphabet.Letter(index))
+			}
+			explanation.KeyedAlphabet = keyed.String()
+		}
+	}
+	return explanation
+}
+
+// LetterValues returns every distinct letter of the phrase, in alphabetical
+// order, with the numbers it was encoded as.
+func (p *Puzzle) LetterValues() []LetterValues {
+	seen := make(map[rune]map[int]bool)
+	var letters []LetterValues
+	for _, pc := range p.Chars {
+		if pc.IsSymbol || pc.Value == 0 {
+			continue
+		}
+		if seen[pc.Char] == nil {
+			seen[pc.Char] = make(map[int]bool)
+			letters = append(letters, LetterValues{Letter: pc.Char})
+		}
+		if !seen[pc.Char][pc.Value] {
+			seen[pc.Char][pc.Value] = true
+			for i := range letters {
+				if letters[i].Letter == pc.Char {
+					letters[i].Values = append(letters[i].Values, pc.Value)
+				}
+			}
+		}
+	}
+	sort.Slice(letters, func(i, j int) bool { return letters[i].Letter < letters[j].Letter })
+	for _, letter := range letters {
+		sort.Ints(letter.Values)
+	}
+	return letters
+}
+
+func modInverse(a, n int) int {
+	a = mod(a, n)
+	for x := 1; x < n; x++ {
+		if a*x%n == 1 {
+			return x
+		}
+	}
+	return 0
+}
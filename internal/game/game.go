@@ -2,8 +2,8 @@ package game
 
 import (
 	"math/rand"
-	"strconv"
 	"strings"
+	"sync"
 	"time"
 	"unicode"
 	"fmt"
@@ -15,54 +15,231 @@ var superscriptMap = map[rune]string{
 }
 
 type PuzzleChar struct {
-	Char      rune
-	IsHidden  bool
-	IsGuessed bool
-	Value     int
+	Char      rune `json:"char"`
+	IsHidden  bool `json:"is_hidden"`
+	IsGuessed bool `json:"is_guessed"`
+	// IsSymbol marks a character of the alphabet's symbols, such as a digit,
+	// which is shown as it is and has no value.
+	IsSymbol bool `json:"is_symbol,omitempty"`
+	Value    int  `json:"value"`
+	// GuessedBy is the ID of the user whose guess filled in the letter, or 0
+	// if it was revealed.
+	GuessedBy int64 `json:"guessed_by,omitempty"`
 }
 
 type Puzzle struct {
-	Chars             []*PuzzleChar
-	Solution          string
-	RemainingSolution string
-	MessageID         int
-	Points            int
+	Chars             []*PuzzleChar `json:"chars"`
+	Solution          string        `json:"solution"`
+	RemainingSolution string        `json:"remaining_solution"`
+	MessageID         int           `json:"message_id"`
+	Format            string        `json:"format,omitempty"`
+	Style             string        `json:"style,omitempty"`
+	Points            int           `json:"points"`
+	Cipher            CipherConfig  `json:"cipher"`
+	Shift             int           `json:"shift"`
+	ValueMode         string        `json:"value_mode,omitempty"`
+	DailyDate         string        `json:"daily_date,omitempty"`
+	Mode              string        `json:"mode,omitempty"`
+	Guesses           int           `json:"guesses"`
+	Difficulty        string        `json:"difficulty"`
+	PuzzleID          string        `json:"puzzle_id"`
+	Language          string        `json:"language,omitempty"`
+	Seed              int64         `json:"seed"`
+	Reveals           int           `json:"reveals"`
+	MaxMistakes       int           `json:"max_mistakes"`
+	MistakePenalty    int           `json:"mistake_penalty"`
+	Mistakes          int           `json:"mistakes"`
+	StartedAt         time.Time     `json:"started_at"`
+	TimeLimit         time.Duration `json:"time_limit"`
+	SpeedBonus        []SpeedBonus  `json:"speed_bonus,omitempty"`
+	// PlayerMistakes and CountdownSent track the wrong letters of every
+	// player and the countdown announcements made in a race.
+	PlayerMistakes map[int64]int `json:"player_mistakes,omitempty"`
+	CountdownSent  int           `json:"countdown_sent,omitempty"`
 }
 
 type Service struct {
 	config *Config
 	random *rand.Rand
+	mu     sync.Mutex
 }
 
 type CheckResult struct {
 	IsCorrect           bool
 	IsPartial           bool
+	// CorrectlyGuessedChars holds the accepted letters, Rejected the letters
+	// that are not among the hidden letters at all and Surplus the letters
+	// that are hidden but were already filled in or guessed too many times.
 	CorrectlyGuessedChars string
+	Rejected              string
+	Surplus               string
+	// Slots holds the indices into Puzzle.Chars credited by a guess that
+	// names exact positions. It is empty for bag-of-letters guesses, which
+	// are credited through UpdateState.
+	Slots []int
 }
 
-func NewService(config *Config) *Service {
-	source := rand.NewSource(time.Now().UnixNano())
+// NewService creates a game service that draws the seed of every puzzle from
+// source. Passing a fixed source makes the whole sequence of puzzles
+// reproducible.
+func NewService(config *Config, source rand.Source) *Service {
 	return &Service{
 		config: config,
 		random: rand.New(source),
 	}
 }
 
-func (s *Service) GeneratePuzzle(difficulty string) (*Puzzle, error) {
+func (s *Service) Config() *Config {
+	return s.config
+}
+
+// GeneratePuzzle creates a new puzzle of the given difficulty. recent holds
+// the IDs of the puzzles the players have seen lately, most recent first, and
+// is used to avoid repeating them.
+func (s *Service) GeneratePuzzle(difficulty string, recent []string) (*Puzzle, error) {
+	return s.newPuzzle(difficulty, ModeClassic, recent)
+}
+
+func (s *Service) newPuzzle(difficulty, mode string, recent []string) (*Puzzle, error) {
+	level, difficulty, err := s.difficultyLevel(difficulty)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	seed := s.random.Int63()
+	s.mu.Unlock()
+
+	puzzleID, err := s.selectPuzzle(level, difficulty, recent, rand.New(rand.NewSource(seed)))
+	if err != nil {
+		return nil, err
+	}
+	return s.generatePuzzle(level, difficulty, puzzleID, mode, seed)
+}
+
+// RegeneratePuzzle rebuilds a puzzle exactly as it was first generated from
+// its difficulty, puzzle ID, mode and seed, including the shift, the hidden
+// letters and, for procedural puzzles, the phrase itself.
+func (s *Service) RegeneratePuzzle(difficulty, puzzleID, mode string, seed int64) (*Puzzle, error) {
+	level, difficulty, err := s.difficultyLevel(difficulty)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.generatePuzzle(level, difficulty, puzzleID, mode, seed)
+}
+
+func (s *Service) difficultyLevel(difficulty string) (DifficultyLevel, string, error) {
 	level, ok := s.config.Difficulties[difficulty]
 	if !ok {
-		level, ok = s.config.Difficulties["easy"]
+		difficulty = s.config.DefaultDifficulty
+		level, ok = s.config.Difficulties[difficulty]
 		if !ok {
-			return nil, fmt.Errorf("easy difficulty level not found in config")
+			return level, difficulty, fmt.Errorf("%s difficulty level not found in config", difficulty)
 		}
 	}
+	return level, difficulty, nil
+}
 
+// selectPuzzle picks the ID of the next puzzle of a level, preferring curated
+// puzzles that are not in recent. Once every puzzle of the level has been seen
+// it only avoids the most recently seen half of them.
+func (s *Service) selectPuzzle(level DifficultyLevel, difficulty string, recent []string, random *rand.Rand) (string, error) {
+	if s.useProcedural(level, random) {
+		return ProceduralPuzzleID, nil
+	}
 	if len(level.Puzzles) == 0 {
-		return nil, fmt.Errorf("no puzzles found for difficulty: %s", difficulty)
+		return "", fmt.Errorf("no puzzles found for difficulty: %s", difficulty)
+	}
+
+	inPool := make(map[string]bool, len(level.Puzzles))
+	for _, puzzle := range level.Puzzles {
+		inPool[puzzle.ID] = true
+	}
+	var poolRecent []string
+	for _, id := range recent {
+		if inPool[id] {
+			poolRecent = append(poolRecent, id)
+		}
+	}
+
+	candidates := puzzlesNotIn(level.Puzzles, poolRecent)
+	if len(candidates) == 0 {
+		candidates = puzzlesNotIn(level.Puzzles, poolRecent[:len(level.Puzzles)/2])
+	}
+	return candidates[random.Intn(len(candidates))].ID, nil
+}
+
+func puzzlesNotIn(puzzles []PuzzleConfig, ids []string) []PuzzleConfig {
+	excluded := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		excluded[id] = true
+	}
+	var result []PuzzleConfig
+	for _, puzzle := range puzzles {
+		if !excluded[puzzle.ID] {
+			result = append(result, puzzle)
+		}
+	}
+	return result
+}
+
+// GenerateDailyPuzzle returns the puzzle shared by every player on the UTC
+// date of day. The puzzle and its shift are drawn from a random source seeded
+// with the date, so every call for the same date yields the same puzzle.
+func (s *Service) GenerateDailyPuzzle(day time.Time) (*Puzzle, error) {
+	difficulty := s.config.Daily.Difficulty
+	if difficulty == "" {
+		difficulty = s.config.DefaultDifficulty
+	}
+	level, ok := s.config.Difficulties[difficulty]
+	if !ok {
+		return nil, fmt.Errorf("daily difficulty level %s not found in config", difficulty)
 	}
 
-	puzzleConfig := level.Puzzles[s.random.Intn(len(level.Puzzles))]
-	word := strings.ToUpper(puzzleConfig.Text)
+	day = day.UTC()
+	seed := int64(day.Year()*10000 + int(day.Month())*100 + day.Day())
+	puzzleID, err := s.selectPuzzle(level, difficulty, nil, rand.New(rand.NewSource(seed)))
+	if err != nil {
+		return nil, err
+	}
+	puzzle, err := s.generatePuzzle(level, difficulty, puzzleID, ModeClassic, seed)
+	if err != nil {
+		return nil, err
+	}
+	puzzle.DailyDate = DailyKey(day)
+	return puzzle, nil
+}
+
+func DailyKey(t time.Time) string {
+	return t.UTC().Format("2006-01-02")
+}
+
+func (s *Service) generatePuzzle(level DifficultyLevel, difficulty, puzzleID, mode string, seed int64) (*Puzzle, error) {
+	random := rand.New(rand.NewSource(seed))
+
+	var puzzleConfig PuzzleConfig
+	hidePercentage := level.HidePercentage
+	if puzzleID == ProceduralPuzzleID {
+		if level.Procedural == nil {
+			return nil, fmt.Errorf("procedural puzzles are not enabled for difficulty: %s", difficulty)
+		}
+		puzzleConfig, hidePercentage = s.proceduralPuzzle(level, random)
+	} else {
+		found := false
+		for _, candidate := range level.Puzzles {
+			if candidate.ID == puzzleID {
+				puzzleConfig, found = candidate, true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("puzzle %s not found for difficulty: %s", puzzleID, difficulty)
+		}
+	}
+	language := s.config.puzzleLanguage(puzzleConfig)
+	alphabet := s.config.Alphabet(language)
+	word := alphabet.NormalizeText(puzzleConfig.Text)
 
 	var finalShift int
 	switch v := puzzleConfig.Shift.(type) {
@@ -70,27 +247,52 @@ func (s *Service) GeneratePuzzle(difficulty string) (*Puzzle, error) {
 		finalShift = v
 	case string:
 		if v == "random" {
-			finalShift = s.random.Intn(10) + 1
+			finalShift = random.Intn(10) + 1
 		}
 	default:
 		finalShift = 0
 	}
+	if mode == ModeShift || mode == ModeEncode {
+		// The player has to recover the shift from the numbers alone, or
+		// the numbers from the shift, so every letter is hidden and a plain
+		// Caesar shift is always used.
+		puzzleConfig.Cipher = CipherConfig{}
+		finalShift = random.Intn(alphabet.Size()-1) + 1
+		hidePercentage = 100
+	}
+
+	valueMode := puzzleValueMode(level, puzzleConfig)
+	cipher, err := NewCipher(puzzleConfig.Cipher, finalShift, valueMode, alphabet)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cipher for puzzle %q: %w", puzzleConfig.Text, err)
+	}
 
 	var puzzleChars []*PuzzleChar
 	var solutionBuilder strings.Builder
 	var letterIndices []int
 
-	for i, char := range word {
-		if unicode.IsLetter(char) {
-			letterIndices = append(letterIndices, i)
+	letterPos := 0
+	for _, char := range word {
+		pc := &PuzzleChar{Char: char}
+		if index, ok := alphabet.Index(char); ok {
+			pc.Value = cipher.Encode(index, letterPos)
+			letterIndices = append(letterIndices, len(puzzleChars))
+			letterPos++
+		} else if alphabet.IsSymbol(char) {
+			pc.IsSymbol = true
+		} else if unicode.IsLetter(char) {
+			return nil, fmt.Errorf("letter %q of puzzle %q is not in the %s alphabet", char, puzzleConfig.Text, language)
+		} else if char != ' ' {
+			continue
 		}
+		puzzleChars = append(puzzleChars, pc)
 	}
 
-	s.random.Shuffle(len(letterIndices), func(i, j int) {
+	random.Shuffle(len(letterIndices), func(i, j int) {
 		letterIndices[i], letterIndices[j] = letterIndices[j], letterIndices[i]
 	})
 
-	hideCount := (len(letterIndices) * level.HidePercentage) / 100
+	hideCount := (len(letterIndices) * hidePercentage) / 100
 	if hideCount == 0 && len(letterIndices) > 1 {
 		hideCount = 1
 	}
@@ -100,14 +302,6 @@ func (s *Service) GeneratePuzzle(difficulty string) (*Puzzle, error) {
 		hideSet[letterIndices[i]] = true
 	}
 
-	for _, char := range word {
-		pc := &PuzzleChar{Char: char}
-		if unicode.IsLetter(char) {
-			pc.Value = int(char-'A') + 1 + finalShift
-		}
-		puzzleChars = append(puzzleChars, pc)
-	}
-
 	for i, pc := range puzzleChars {
 		if hideSet[i] {
 			pc.IsHidden = true
@@ -115,45 +309,141 @@ func (s *Service) GeneratePuzzle(difficulty string) (*Puzzle, error) {
 		}
 	}
 
+	cipherConfig := puzzleConfig.Cipher
+	cipherConfig.Name = cipher.Name()
+
 	solution := solutionBuilder.String()
-	return &Puzzle{
+	puzzle := &Puzzle{
 		Chars:             puzzleChars,
 		Solution:          solution,
 		RemainingSolution: solution,
 		Points:            level.Points,
-	}, nil
+		Cipher:            cipherConfig,
+		Shift:             finalShift,
+		Mode:              mode,
+		ValueMode:         valueMode,
+		Difficulty:        difficulty,
+		PuzzleID:          puzzleConfig.ID,
+		Language:          language,
+		Seed:              seed,
+		MaxMistakes:       level.MaxMistakes,
+		MistakePenalty:    level.MistakePenalty,
+		TimeLimit:         level.TimeLimit,
+		SpeedBonus:        level.SpeedBonus,
+	}
+	switch mode {
+	case ModeRace:
+		puzzle.MaxMistakes = 0
+		puzzle.TimeLimit = s.config.Race.TimeLimit
+		puzzle.SpeedBonus = nil
+	case ModeDuel:
+		puzzle.MaxMistakes = 0
+		puzzle.TimeLimit = s.config.Duel.TimeLimit
+		puzzle.SpeedBonus = nil
+	}
+	return puzzle, nil
 }
 
-func (p *Puzzle) RevealAll() {
+// RevealAll fills in every hidden letter still missing and credits them to
+// userID, or to nobody when userID is 0.
+func (p *Puzzle) RevealAll(userID int64) {
 	for _, pc := range p.Chars {
-		if pc.IsHidden {
+		if pc.IsHidden && !pc.IsGuessed {
 			pc.IsGuessed = true
+			pc.GuessedBy = userID
 		}
 	}
 }
 
+// RenderDisplay renders the puzzle as text in the default superscript style.
 func (p *Puzzle) RenderDisplay() string {
-	var displayBuilder strings.Builder
-	for _, pc := range p.Chars {
-		if pc.Char == ' ' {
-			displayBuilder.WriteString("\n")
-			continue
+	return p.RenderStyle(StyleSuperscript)
+}
+
+func (p *Puzzle) renderLives() string {
+	left := p.LivesLeft()
+	return strings.Repeat("❤️", left) + strings.Repeat("🖤", p.MaxMistakes-left)
+}
+
+// RecordMistakes charges n wrong letters against the puzzle's mistake budget.
+func (p *Puzzle) RecordMistakes(n int) {
+	p.Mistakes += n
+}
+
+func (p *Puzzle) LivesLeft() int {
+	left := p.MaxMistakes - p.Mistakes
+	if left < 0 {
+		return 0
+	}
+	return left
+}
+
+// OutOfLives reports whether the mistake budget is used up. Puzzles without a
+// budget never run out.
+func (p *Puzzle) OutOfLives() bool {
+	return p.MaxMistakes > 0 && p.Mistakes >= p.MaxMistakes
+}
+
+// Expired reports whether the puzzle has a time limit that ran out by now.
+func (p *Puzzle) Expired(now time.Time) bool {
+	return p.TimeLimit > 0 && now.Sub(p.StartedAt) >= p.TimeLimit
+}
+
+// SpeedBonusFor returns the bonus points for solving the puzzle after elapsed
+// time, taken from the first step of the bonus curve that covers it.
+func (p *Puzzle) SpeedBonusFor(elapsed time.Duration) int {
+	for _, step := range p.SpeedBonus {
+		if elapsed <= step.Within {
+			return step.Bonus
 		}
-		if !unicode.IsLetter(pc.Char) {
-			continue
+	}
+	return 0
+}
+
+// Reward returns the points for solving the puzzle after the mistake
+// penalties are deducted.
+func (p *Puzzle) Reward() int {
+	reward := p.Points - p.Mistakes*p.MistakePenalty
+	if reward < 0 {
+		return 0
+	}
+	return reward
+}
+
+// HiddenSlots returns the indices into Chars of every hidden letter in phrase
+// order, whether it has been guessed yet or not. Positional guesses number
+// these slots starting from 1.
+func (p *Puzzle) HiddenSlots() []int {
+	var slots []int
+	for i, pc := range p.Chars {
+		if pc.IsHidden {
+			slots = append(slots, i)
 		}
+	}
+	return slots
+}
 
-		superScript := toSuperscript(pc.Value)
+// ApplyResult fills in the letters accepted by a guess of userID.
+func (p *Puzzle) ApplyResult(result *CheckResult, userID int64) {
+	if len(result.Slots) == 0 {
+		p.UpdateState(result.CorrectlyGuessedChars, userID)
+		return
+	}
+
+	for _, i := range result.Slots {
+		p.Chars[i].IsGuessed = true
+		p.Chars[i].GuessedBy = userID
+	}
+	var remaining strings.Builder
+	for _, pc := range p.Chars {
 		if pc.IsHidden && !pc.IsGuessed {
-			displayBuilder.WriteString("(_" + superScript + ")")
-		} else {
-			displayBuilder.WriteString("(" + string(pc.Char) + superScript + ")")
+			remaining.WriteRune(pc.Char)
 		}
 	}
-	return displayBuilder.String()
+	p.RemainingSolution = remaining.String()
 }
 
-func (p *Puzzle) UpdateState(guessedChars string) {
+func (p *Puzzle) UpdateState(guessedChars string, userID int64) {
 	guessedMap := make(map[rune]int)
 	for _, r := range guessedChars {
 		guessedMap[r]++
@@ -178,56 +468,77 @@ func (p *Puzzle) UpdateState(guessedChars string) {
 		if pc.IsHidden && !pc.IsGuessed {
 			if count, ok := guessedMap[pc.Char]; ok && count > 0 {
 				pc.IsGuessed = true
+				pc.GuessedBy = userID
 				guessedMap[pc.Char]--
 			}
 		}
 	}
 }
 
-func (s *Service) CheckAnswer(remainingSolution, guess string) *CheckResult {
-	guess = strings.ToUpper(guess)
-	result := &CheckResult{}
-
-	tempSolution := remainingSolution
-	for _, char := range guess {
-		if !strings.ContainsRune(tempSolution, char) {
-			return result // Kembalikan hasil kosong (salah total)
-		}
-	}
-
-	if guess == remainingSolution {
-		result.IsCorrect = true
-		result.CorrectlyGuessedChars = guess // Penambahan penting di sini
+func (s *Service) CheckAnswer(puzzle *Puzzle, guess string) *CheckResult {
+	guess = s.config.Alphabet(puzzle.Language).NormalizeText(guess)
+	if result := checkPhrase(puzzle, guess); result != nil {
 		return result
 	}
 
-	var guessedCharsBuilder strings.Builder
-	solutionMap := make(map[rune]int)
-	for _, r := range remainingSolution {
-		solutionMap[r]++
+	remaining := make(map[rune]int)
+	for _, r := range puzzle.RemainingSolution {
+		remaining[r]++
 	}
 
+	result := &CheckResult{}
+	var accepted, rejected, surplus strings.Builder
 	for _, r := range guess {
-		if count, ok := solutionMap[r]; ok && count > 0 {
-			guessedCharsBuilder.WriteRune(r)
-			solutionMap[r]--
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		switch {
+		case remaining[r] > 0:
+			accepted.WriteRune(r)
+			remaining[r]--
+		case strings.ContainsRune(puzzle.Solution, r):
+			surplus.WriteRune(r)
+		case puzzle.shows(r):
+			// A near miss at the whole phrase repeats the letters already
+			// on show, which are not mistakes.
+		default:
+			rejected.WriteRune(r)
 		}
 	}
+	result.Rejected = rejected.String()
+	result.Surplus = surplus.String()
 
-	guessedStr := guessedCharsBuilder.String()
-	if len(guessedStr) > 0 {
+	guessedStr := accepted.String()
+	if len(guessedStr) == 0 {
+		return result
+	}
+	result.CorrectlyGuessedChars = guessedStr
+	if len([]rune(guessedStr)) == len([]rune(puzzle.RemainingSolution)) {
+		result.IsCorrect = true
+	} else {
 		result.IsPartial = true
-		result.CorrectlyGuessedChars = guessedStr
 	}
-
 	return result
 }
 
-func toSuperscript(n int) string {
-	s := strconv.Itoa(n)
+// shows reports whether letter r is visible in the puzzle from the start.
+func (p *Puzzle) shows(r rune) bool {
+	for _, pc := range p.Chars {
+		if pc.Char == r && !pc.IsHidden {
+			return true
+		}
+	}
+	return false
+}
+
+func toSuperscript(s string) string {
 	var result strings.Builder
 	for _, r := range s {
-		result.WriteString(superscriptMap[r])
+		if sup, ok := superscriptMap[r]; ok {
+			result.WriteString(sup)
+		} else {
+			result.WriteRune(r)
+		}
 	}
 	return result.String()
 }
@@ -247,14 +558,18 @@ func (p *Puzzle) RevealRandomChar() (revealedChar rune, success bool) {
 		return 0, false
 	}
 
-	rand.Shuffle(len(hiddenIndices), func(i, j int) {
+	// Each reveal draws from its own source derived from the puzzle seed, so
+	// the sequence of revealed letters can be replayed from the seed alone.
+	random := rand.New(rand.NewSource(p.Seed + int64(p.Reveals)))
+	random.Shuffle(len(hiddenIndices), func(i, j int) {
 		hiddenIndices[i], hiddenIndices[j] = hiddenIndices[j], hiddenIndices[i]
 	})
 	
 	revealIndex := hiddenIndices[0]
 	revealedChar = p.Chars[revealIndex].Char
+	p.Reveals++
 	
-	p.UpdateState(string(revealedChar))
+	p.UpdateState(string(revealedChar), 0)
 
 	return revealedChar, true
 }
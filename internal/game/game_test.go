@@ -0,0 +1,52 @@
+package game
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// newTestPuzzle builds a puzzle of text whose letters in hidden are hidden.
+func newTestPuzzle(text, hidden string) *Puzzle {
+	puzzle := &Puzzle{}
+	var solution strings.Builder
+	for i, r := range text {
+		pc := &PuzzleChar{Char: r, Value: i + 1}
+		if strings.ContainsRune(hidden, r) {
+			pc.IsHidden = true
+			solution.WriteRune(r)
+		}
+		puzzle.Chars = append(puzzle.Chars, pc)
+	}
+	puzzle.Solution = solution.String()
+	puzzle.RemainingSolution = puzzle.Solution
+	return puzzle
+}
+
+func TestCheckAnswerNearMissPhrase(t *testing.T) {
+	service := NewService(&Config{defaultAlphabet: newDefaultAlphabet()}, rand.NewSource(1))
+
+	tests := []struct {
+		name        string
+		text        string
+		hidden      string
+		guess       string
+		wantCorrect bool
+		wantRejects string
+	}{
+		{"one letter off", "KOPI SUSU", "PIS", "KOPI SUSO", true, ""},
+		{"wrong word", "KOPI SUSU", "PIS", "KOPI TEH", false, "TEH"},
+		{"wrong phrase", "SAMBAL AYAM", "BLY", "SAMPAH AYAM", false, "PH"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := service.CheckAnswer(newTestPuzzle(tt.text, tt.hidden), tt.guess)
+			if result.IsCorrect != tt.wantCorrect {
+				t.Errorf("IsCorrect = %v, want %v", result.IsCorrect, tt.wantCorrect)
+			}
+			if result.Rejected != tt.wantRejects {
+				t.Errorf("Rejected = %q, want %q", result.Rejected, tt.wantRejects)
+			}
+		})
+	}
+}
@@ -0,0 +1,237 @@
+package game
+
+import (
+	"bufio"
+	"bytes"
+	_ "embed"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"strings"
+	"unicode/utf8"
+)
+
+// Puzzle message formats. Image puzzles are sent as a PNG card, text puzzles
+// as the RenderDisplay text, which is the fallback for chats that prefer it.
+const (
+	FormatImage = "image"
+	FormatText  = "text"
+)
+
+//go:embed fonts/bitmap5x7.txt
+var bitmapFontData string
+
+const (
+	glyphWidth  = 5
+	glyphHeight = 7
+	missingRune = '□'
+)
+
+type bitmapFont map[rune][glyphHeight]string
+
+var cardFont = mustParseBitmapFont(bitmapFontData)
+
+func mustParseBitmapFont(data string) bitmapFont {
+	font, err := parseBitmapFont(data)
+	if err != nil {
+		panic(fmt.Sprintf("invalid embedded font: %v", err))
+	}
+	return font
+}
+
+func parseBitmapFont(data string) (bitmapFont, error) {
+	font := make(bitmapFont)
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "# ") {
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(line)
+		if size != len(line) {
+			return nil, fmt.Errorf("glyph header %q must be a single character", line)
+		}
+		var glyph [glyphHeight]string
+		for row := range glyph {
+			if !scanner.Scan() || utf8.RuneCountInString(scanner.Text()) != glyphWidth {
+				return nil, fmt.Errorf("glyph %q must have %d rows of %d pixels", r, glyphHeight, glyphWidth)
+			}
+			glyph[row] = scanner.Text()
+		}
+		font[r] = glyph
+	}
+	if _, ok := font[missingRune]; !ok {
+		return nil, fmt.Errorf("font has no glyph for %q", missingRune)
+	}
+	return font, scanner.Err()
+}
+
+// drawText draws text with its top left corner at (x, y), every font pixel
+// scaled to a scale×scale square, and returns the width it took.
+func (f bitmapFont) drawText(img draw.Image, x, y, scale int, text string, c color.Color) int {
+	src := image.NewUniform(c)
+	start := x
+	for _, r := range text {
+		glyph, ok := f[r]
+		if !ok {
+			glyph = f[missingRune]
+		}
+		for row, pixels := range glyph {
+			for col, pixel := range []rune(pixels) {
+				if pixel == '#' {
+					rect := image.Rect(x+col*scale, y+row*scale, x+(col+1)*scale, y+(row+1)*scale)
+					draw.Draw(img, rect, src, image.Point{}, draw.Src)
+				}
+			}
+		}
+		x += (glyphWidth + 1) * scale
+	}
+	if x == start {
+		return 0
+	}
+	return x - start - scale
+}
+
+func textWidth(text string, scale int) int {
+	n := utf8.RuneCountInString(text)
+	if n == 0 {
+		return 0
+	}
+	return n*(glyphWidth+1)*scale - scale
+}
+
+const (
+	cardMargin     = 24
+	cardGap        = 8
+	cardLetterSize = 6
+	cardValueSize  = 3
+	cardColumns    = 10
+	cellWidth      = 56
+	cellHeight     = 64
+	valueHeight    = glyphHeight*cardValueSize + 8
+)
+
+var (
+	cardBackground  = color.RGBA{0x1e, 0x1e, 0x2e, 0xff}
+	cardCell        = color.RGBA{0xf5, 0xf5, 0xf5, 0xff}
+	cardHiddenCell  = color.RGBA{0x3b, 0x42, 0x61, 0xff}
+	cardLetter      = color.RGBA{0x1e, 0x1e, 0x2e, 0xff}
+	cardGuessed     = color.RGBA{0x2e, 0x9e, 0x5b, 0xff}
+	cardUnderscore  = color.RGBA{0xc0, 0xc8, 0xe8, 0xff}
+	cardValue       = color.RGBA{0xf9, 0xd9, 0x6b, 0xff}
+	cardSymbol      = color.RGBA{0xc0, 0xc8, 0xe8, 0xff}
+	cardLifeLeft    = color.RGBA{0xe6, 0x39, 0x46, 0xff}
+	cardLifeSpent   = color.RGBA{0x55, 0x55, 0x66, 0xff}
+	cardBorderColor = color.RGBA{0x59, 0x63, 0x8a, 0xff}
+)
+
+// cardRows splits the puzzle into the rows of the image card: one row per
+// word, with words longer than cardColumns wrapped onto the next row.
+func (p *Puzzle) cardRows() [][]*PuzzleChar {
+	var rows [][]*PuzzleChar
+	for _, word := range p.displayWords() {
+		for len(word) > cardColumns {
+			rows = append(rows, word[:cardColumns])
+			word = word[cardColumns:]
+		}
+		rows = append(rows, word)
+	}
+	return rows
+}
+
+// CanRenderImage reports whether the card font has a glyph for every letter
+// and symbol of the puzzle. Alphabets beyond A-Z would otherwise be drawn as
+// unreadable boxes.
+func (p *Puzzle) CanRenderImage() bool {
+	for _, word := range p.displayWords() {
+		for _, pc := range word {
+			if _, ok := cardFont[pc.Char]; !ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// RenderImage draws the puzzle as a PNG card. Every letter gets a cell with
+// its value underneath, hidden letters are dark cells with an underscore and
+// guessed letters are shown in green. Symbols are drawn as they are.
+func (p *Puzzle) RenderImage() ([]byte, error) {
+	rows := p.cardRows()
+	columns := 1
+	for _, row := range rows {
+		if len(row) > columns {
+			columns = len(row)
+		}
+	}
+	rowHeight := cellHeight + valueHeight + cardGap
+	livesHeight := 0
+	if p.MaxMistakes > 0 {
+		livesHeight = glyphHeight*cardValueSize*2 + cardGap
+	}
+
+	lifeWidth := textWidth(strings.Repeat("♥", p.MaxMistakes), cardValueSize*2)
+	width := columns*(cellWidth+cardGap) - cardGap + 2*cardMargin
+	if lifeWidth+2*cardMargin > width {
+		width = lifeWidth + 2*cardMargin
+	}
+	height := len(rows)*rowHeight - cardGap + livesHeight + 2*cardMargin
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(cardBackground), image.Point{}, draw.Src)
+
+	y := cardMargin
+	for _, row := range rows {
+		x := (width - (len(row)*(cellWidth+cardGap) - cardGap)) / 2
+		for _, pc := range row {
+			p.drawCell(img, x, y, pc)
+			x += cellWidth + cardGap
+		}
+		y += rowHeight
+	}
+
+	if p.MaxMistakes > 0 {
+		left := p.LivesLeft()
+		x := (width - lifeWidth) / 2
+		x += cardFont.drawText(img, x, y, cardValueSize*2, strings.Repeat("♥", left), cardLifeLeft)
+		if left > 0 {
+			x += cardValueSize * 2
+		}
+		cardFont.drawText(img, x, y, cardValueSize*2, strings.Repeat("♥", p.MaxMistakes-left), cardLifeSpent)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (p *Puzzle) drawCell(img draw.Image, x, y int, pc *PuzzleChar) {
+	letterX := x + (cellWidth-textWidth("W", cardLetterSize))/2
+	letterY := y + (cellHeight-glyphHeight*cardLetterSize)/2
+	if pc.IsSymbol {
+		cardFont.drawText(img, letterX, letterY, cardLetterSize, string(pc.Char), cardSymbol)
+		return
+	}
+
+	letter, value := p.cell(pc)
+	cell := image.Rect(x, y, x+cellWidth, y+cellHeight)
+	switch {
+	case pc.isBlank():
+		draw.Draw(img, cell, image.NewUniform(cardBorderColor), image.Point{}, draw.Src)
+		draw.Draw(img, cell.Inset(2), image.NewUniform(cardHiddenCell), image.Point{}, draw.Src)
+		cardFont.drawText(img, letterX, letterY, cardLetterSize, letter, cardUnderscore)
+	case pc.IsHidden:
+		draw.Draw(img, cell, image.NewUniform(cardCell), image.Point{}, draw.Src)
+		cardFont.drawText(img, letterX, letterY, cardLetterSize, letter, cardGuessed)
+	default:
+		draw.Draw(img, cell, image.NewUniform(cardCell), image.Point{}, draw.Src)
+		cardFont.drawText(img, letterX, letterY, cardLetterSize, letter, cardLetter)
+	}
+
+	valueX := x + (cellWidth-textWidth(value, cardValueSize))/2
+	cardFont.drawText(img, valueX, y+cellHeight+6, cardValueSize, value, cardValue)
+}
@@ -0,0 +1,120 @@
+package game
+
+import (
+	"strings"
+	"unicode"
+)
+
+// checkPhrase credits a guess that spells out the whole plaintext, with or
+// without spaces, or one or more complete words of it. It returns nil when the
+// guess is not a phrase or word match that fills in at least one hidden slot.
+func checkPhrase(puzzle *Puzzle, guess string) *CheckResult {
+	words := puzzle.words()
+
+	var phrase strings.Builder
+	for _, word := range words {
+		phrase.WriteString(word.text)
+	}
+	if lettersOnly(guess) == phrase.String() {
+		return slotResult(puzzle, puzzle.unguessedSlots(0, len(puzzle.Chars)))
+	}
+
+	var slots []int
+	for _, guessWord := range strings.Fields(guess) {
+		guessWord = lettersOnly(guessWord)
+		if len([]rune(guessWord)) < 2 {
+			return nil
+		}
+		matched := false
+		for _, word := range words {
+			if word.text == guessWord {
+				slots = append(slots, puzzle.unguessedSlots(word.start, word.end)...)
+				matched = true
+			}
+		}
+		if !matched {
+			return nil
+		}
+	}
+	if len(slots) == 0 {
+		return nil
+	}
+	return slotResult(puzzle, slots)
+}
+
+type phraseWord struct {
+	text       string
+	start, end int
+}
+
+// words splits the puzzle into its space separated words, keeping the range
+// of Chars each word covers so matching words can be credited.
+func (p *Puzzle) words() []phraseWord {
+	var words []phraseWord
+	var current strings.Builder
+	start := 0
+	for i, pc := range p.Chars {
+		if pc.Char == ' ' {
+			if current.Len() > 0 {
+				words = append(words, phraseWord{text: current.String(), start: start, end: i})
+				current.Reset()
+			}
+			start = i + 1
+			continue
+		}
+		if unicode.IsLetter(pc.Char) {
+			current.WriteRune(pc.Char)
+		}
+	}
+	if current.Len() > 0 {
+		words = append(words, phraseWord{text: current.String(), start: start, end: len(p.Chars)})
+	}
+	return words
+}
+
+func (p *Puzzle) unguessedSlots(start, end int) []int {
+	var slots []int
+	for i := start; i < end; i++ {
+		if p.Chars[i].IsHidden && !p.Chars[i].IsGuessed {
+			slots = append(slots, i)
+		}
+	}
+	return slots
+}
+
+// slotResult builds the result of a guess that fills in the given slots.
+func slotResult(puzzle *Puzzle, slots []int) *CheckResult {
+	result := &CheckResult{}
+	if len(slots) == 0 {
+		return result
+	}
+
+	seen := make(map[int]bool)
+	var guessedBuilder strings.Builder
+	for _, slot := range slots {
+		if seen[slot] {
+			continue
+		}
+		seen[slot] = true
+		result.Slots = append(result.Slots, slot)
+		guessedBuilder.WriteRune(puzzle.Chars[slot].Char)
+	}
+
+	result.CorrectlyGuessedChars = guessedBuilder.String()
+	if len(result.Slots) == len([]rune(puzzle.RemainingSolution)) {
+		result.IsCorrect = true
+	} else {
+		result.IsPartial = true
+	}
+	return result
+}
+
+func lettersOnly(s string) string {
+	var builder strings.Builder
+	for _, r := range s {
+		if unicode.IsLetter(r) {
+			builder.WriteRune(r)
+		}
+	}
+	return builder.String()
+}
@@ -0,0 +1,83 @@
+package game
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// IsPositionalGuess reports whether guess uses the positional syntax, such as
+// "3=K" or "2,5=AN", instead of a plain set of letters.
+func IsPositionalGuess(guess string) bool {
+	return strings.Contains(guess, "=")
+}
+
+// ParsePositionalGuess splits a positional guess into the 1-based hidden slot
+// numbers and the letters guessed for them.
+func ParsePositionalGuess(guess string) ([]int, []rune, error) {
+	parts := strings.SplitN(guess, "=", 2)
+	if len(parts) != 2 {
+		return nil, nil, fmt.Errorf("positional guess must contain '='")
+	}
+
+	var positions []int
+	for _, field := range strings.FieldsFunc(parts[0], func(r rune) bool { return r == ',' || unicode.IsSpace(r) }) {
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid position %q", field)
+		}
+		positions = append(positions, n)
+	}
+
+	var letters []rune
+	for _, r := range strings.ToUpper(parts[1]) {
+		if r == ',' || unicode.IsSpace(r) {
+			continue
+		}
+		if !unicode.IsLetter(r) {
+			return nil, nil, fmt.Errorf("invalid letter %q", r)
+		}
+		letters = append(letters, r)
+	}
+
+	if len(positions) == 0 || len(positions) != len(letters) {
+		return nil, nil, fmt.Errorf("got %d position(s) for %d letter(s)", len(positions), len(letters))
+	}
+	return positions, letters, nil
+}
+
+// CheckPositionalAnswer checks a guess such as "2,5=AN" against the exact
+// hidden slots it names. Slots that were already filled in are ignored.
+func (s *Service) CheckPositionalAnswer(puzzle *Puzzle, guess string) (*CheckResult, error) {
+	positions, letters, err := ParsePositionalGuess(s.config.Alphabet(puzzle.Language).NormalizeText(guess))
+	if err != nil {
+		return nil, err
+	}
+
+	slots := puzzle.HiddenSlots()
+	for _, pos := range positions {
+		if pos < 1 || pos > len(slots) {
+			return nil, fmt.Errorf("position %d is out of range 1-%d", pos, len(slots))
+		}
+	}
+
+	var credited []int
+	var rejected, surplus strings.Builder
+	for i, pos := range positions {
+		pc := puzzle.Chars[slots[pos-1]]
+		switch {
+		case pc.Char != letters[i]:
+			rejected.WriteRune(letters[i])
+		case pc.IsGuessed:
+			surplus.WriteRune(letters[i])
+		default:
+			credited = append(credited, slots[pos-1])
+		}
+	}
+
+	result := slotResult(puzzle, credited)
+	result.Rejected = rejected.String()
+	result.Surplus = surplus.String()
+	return result, nil
+}
@@ -0,0 +1,146 @@
+package game
+
+import (
+	"math"
+	"math/rand"
+	"strings"
+	"unicode"
+)
+
+// ProceduralConfig lets a difficulty level build puzzles from the dictionary
+// instead of, or alongside, its curated puzzle list.
+type ProceduralConfig struct {
+	// Chance is the percentage of puzzles generated from the dictionary when
+	// the level also has curated puzzles.
+	Chance     int      `yaml:"chance"`
+	Language   string   `yaml:"language"`
+	Categories []string `yaml:"categories"`
+	MinWords   int      `yaml:"min_words"`
+	MaxWords   int      `yaml:"max_words"`
+	// MinScore and MaxScore bound the estimated difficulty of the generated
+	// phrase, see EstimateDifficulty.
+	MinScore float64 `yaml:"min_score"`
+	MaxScore float64 `yaml:"max_score"`
+	// HideJitter moves the level's hide percentage up or down by at most this
+	// many percentage points.
+	HideJitter int `yaml:"hide_jitter"`
+	MaxShift   int `yaml:"max_shift"`
+}
+
+const proceduralAttempts = 50
+
+// ProceduralPuzzleID is the puzzle ID of every puzzle built from the
+// dictionary. Such puzzles are told apart by their seed instead.
+const ProceduralPuzzleID = "procedural"
+
+// letterFrequencies holds the relative frequency, in percent, of each letter in
+// running text of a language.
+var letterFrequencies = map[string]map[rune]float64{
+	"id": {
+		'A': 18.9, 'N': 9.5, 'E': 8.3, 'I': 7.6, 'K': 5.6, 'U': 5.2, 'T': 4.8,
+		'R': 4.6, 'S': 4.5, 'M': 4.3, 'D': 4.2, 'G': 3.9, 'L': 3.4, 'B': 2.6,
+		'P': 2.6, 'O': 2.6, 'H': 2.4, 'Y': 1.9, 'J': 0.9, 'C': 0.7, 'W': 0.6,
+		'F': 0.2, 'V': 0.2, 'Z': 0.05, 'X': 0.03, 'Q': 0.01,
+	},
+	"en": {
+		'E': 12.7, 'T': 9.1, 'A': 8.2, 'O': 7.5, 'I': 7.0, 'N': 6.7, 'S': 6.3,
+		'H': 6.1, 'R': 6.0, 'D': 4.3, 'L': 4.0, 'C': 2.8, 'U': 2.8, 'M': 2.4,
+		'W': 2.4, 'F': 2.2, 'G': 2.0, 'Y': 2.0, 'P': 1.9, 'B': 1.5, 'V': 1.0,
+		'K': 0.8, 'J': 0.15, 'X': 0.15, 'Q': 0.10, 'Z': 0.07,
+	},
+}
+
+// EstimateDifficulty scores how hard a phrase is to guess. Longer phrases and
+// phrases made of rare letters score higher: every letter adds 0.25 and the
+// average rarity of the letters, from 0 for the most common letter of the
+// language to 1 for one that never occurs, adds up to 4.
+func EstimateDifficulty(text, language string) float64 {
+	frequencies, ok := letterFrequencies[language]
+	if !ok {
+		frequencies = letterFrequencies["id"]
+	}
+	var maxFrequency float64
+	for _, f := range frequencies {
+		maxFrequency = math.Max(maxFrequency, f)
+	}
+
+	var letters int
+	var rarity float64
+	for _, r := range strings.ToUpper(text) {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		rarity += 1 - frequencies[r]/maxFrequency
+	}
+	if letters == 0 {
+		return 0
+	}
+	return float64(letters)*0.25 + rarity/float64(letters)*4
+}
+
+func (s *Service) useProcedural(level DifficultyLevel, random *rand.Rand) bool {
+	if level.Procedural == nil {
+		return false
+	}
+	if len(s.config.Dictionary.Words(level.Procedural.Language, level.Procedural.Categories)) == 0 {
+		return false
+	}
+	return len(level.Puzzles) == 0 || random.Intn(100) < level.Procedural.Chance
+}
+
+// proceduralPuzzle builds a phrase from dictionary words whose estimated
+// difficulty falls inside the level's score range, or the closest one found,
+// and picks its shift and hide percentage.
+func (s *Service) proceduralPuzzle(level DifficultyLevel, random *rand.Rand) (PuzzleConfig, int) {
+	cfg := level.Procedural
+	words := s.config.Dictionary.Words(cfg.Language, cfg.Categories)
+
+	minWords, maxWords := cfg.MinWords, cfg.MaxWords
+	if minWords < 1 {
+		minWords = 1
+	}
+	if maxWords < minWords {
+		maxWords = minWords
+	}
+
+	var best string
+	bestDistance := math.Inf(1)
+	for attempt := 0; attempt < proceduralAttempts && bestDistance > 0; attempt++ {
+		count := minWords + random.Intn(maxWords-minWords+1)
+		phrase := make([]string, count)
+		for i := range phrase {
+			phrase[i] = words[random.Intn(len(words))]
+		}
+		text := strings.Join(phrase, " ")
+
+		score := EstimateDifficulty(text, cfg.Language)
+		distance := 0.0
+		if score < cfg.MinScore {
+			distance = cfg.MinScore - score
+		} else if cfg.MaxScore > 0 && score > cfg.MaxScore {
+			distance = score - cfg.MaxScore
+		}
+		if distance < bestDistance {
+			best, bestDistance = text, distance
+		}
+	}
+
+	maxShift := cfg.MaxShift
+	if maxShift < 1 {
+		maxShift = 10
+	}
+
+	hidePercentage := level.HidePercentage
+	if cfg.HideJitter > 0 {
+		hidePercentage += random.Intn(2*cfg.HideJitter+1) - cfg.HideJitter
+	}
+	if hidePercentage < 1 {
+		hidePercentage = 1
+	}
+	if hidePercentage > 100 {
+		hidePercentage = 100
+	}
+
+	return PuzzleConfig{ID: ProceduralPuzzleID, Text: best, Language: cfg.Language, Shift: random.Intn(maxShift) + 1}, hidePercentage
+}
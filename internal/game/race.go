@@ -0,0 +1,129 @@
+package game
+
+import (
+	"sort"
+	"time"
+)
+
+// ModeRace is the group race mode: every player guesses at the same puzzle
+// and the letters each one fills in go on their own tally. The race ends when
+// the phrase is complete or its time runs out.
+const ModeRace = "race"
+
+const defaultRaceTimeLimit = 3 * time.Minute
+
+// RaceConfig sets the rules of /race. Countdown lists how long before the end
+// the remaining time is announced, PointsPerLetter is paid for every letter on
+// a player's tally after their wrong letters are taken off, and Podium holds
+// the extra points for the first, second and third place.
+type RaceConfig struct {
+	TimeLimit       time.Duration   `yaml:"time_limit"`
+	Countdown       []time.Duration `yaml:"countdown"`
+	PointsPerLetter int             `yaml:"points_per_letter"`
+	Podium          []int           `yaml:"podium"`
+}
+
+// Standing is the result of one player in a race.
+type Standing struct {
+	UserID   int64
+	Letters  int
+	Mistakes int
+	Points   int
+}
+
+// GenerateRacePuzzle creates a race puzzle of the given difficulty, see
+// ModeRace. Race puzzles use the race time limit instead of the level's and
+// have no shared lives.
+func (s *Service) GenerateRacePuzzle(difficulty string, recent []string) (*Puzzle, error) {
+	return s.newPuzzle(difficulty, ModeRace, recent)
+}
+
+// RecordPlayerMistakes charges n wrong letters against the race tally of
+// userID.
+func (p *Puzzle) RecordPlayerMistakes(userID int64, n int) {
+	if n == 0 {
+		return
+	}
+	if p.PlayerMistakes == nil {
+		p.PlayerMistakes = make(map[int64]int)
+	}
+	p.PlayerMistakes[userID] += n
+}
+
+// PlayerLetters returns the number of hidden letters userID filled in.
+func (p *Puzzle) PlayerLetters(userID int64) int {
+	letters := 0
+	for _, pc := range p.Chars {
+		if pc.IsGuessed && pc.GuessedBy == userID {
+			letters++
+		}
+	}
+	return letters
+}
+
+// NextCountdown reports whether a countdown announcement is due at now and
+// returns the time left. Every checkpoint of the race countdown is announced
+// once; checkpoints missed in between are skipped.
+func (p *Puzzle) NextCountdown(now time.Time, countdown []time.Duration) (time.Duration, bool) {
+	left := p.TimeLimit - now.Sub(p.StartedAt)
+	due := false
+	for p.CountdownSent < len(countdown) && left <= countdown[p.CountdownSent] {
+		p.CountdownSent++
+		due = true
+	}
+	return left, due
+}
+
+// RaceStandings ranks the players of a race by their score: letters filled in
+// minus wrong letters, ties broken by letters and then by fewer mistakes.
+// Points holds the letter points plus any podium bonus.
+func (s *Service) RaceStandings(puzzle *Puzzle) []Standing {
+	var standings []Standing
+	index := make(map[int64]int)
+	add := func(userID int64) *Standing {
+		if i, ok := index[userID]; ok {
+			return &standings[i]
+		}
+		index[userID] = len(standings)
+		standings = append(standings, Standing{UserID: userID})
+		return &standings[len(standings)-1]
+	}
+	for _, pc := range puzzle.Chars {
+		if pc.IsGuessed && pc.GuessedBy != 0 {
+			add(pc.GuessedBy).Letters++
+		}
+	}
+	for userID, mistakes := range puzzle.PlayerMistakes {
+		add(userID).Mistakes = mistakes
+	}
+
+	sort.SliceStable(standings, func(i, j int) bool {
+		a, b := standings[i], standings[j]
+		if a.score() != b.score() {
+			return a.score() > b.score()
+		}
+		if a.Letters != b.Letters {
+			return a.Letters > b.Letters
+		}
+		if a.Mistakes != b.Mistakes {
+			return a.Mistakes < b.Mistakes
+		}
+		return a.UserID < b.UserID
+	})
+
+	race := s.config.Race
+	for i := range standings {
+		standings[i].Points = standings[i].score() * race.PointsPerLetter
+		if i < len(race.Podium) && standings[i].score() > 0 {
+			standings[i].Points += race.Podium[i]
+		}
+	}
+	return standings
+}
+
+func (st Standing) score() int {
+	if st.Mistakes >= st.Letters {
+		return 0
+	}
+	return st.Letters - st.Mistakes
+}
@@ -0,0 +1,207 @@
+package game
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	StyleSuperscript = "superscript"
+	StyleGrid        = "grid"
+	StylePlain       = "plain"
+	StyleEmoji       = "emoji"
+)
+
+// DisplayStyles lists the text display styles in the order they are offered.
+var DisplayStyles = []string{StyleSuperscript, StyleGrid, StylePlain, StyleEmoji}
+
+// Renderer draws the letters of a puzzle as text. The lives line is added by
+// RenderStyle, so renderers only deal with the phrase itself.
+type Renderer interface {
+	Name() string
+	Render(p *Puzzle) string
+}
+
+func NewRenderer(style string) (Renderer, error) {
+	switch strings.ToLower(style) {
+	case "", StyleSuperscript:
+		return superscriptRenderer{}, nil
+	case StyleGrid:
+		return gridRenderer{}, nil
+	case StylePlain:
+		return plainRenderer{}, nil
+	case StyleEmoji:
+		return emojiRenderer{}, nil
+	default:
+		return nil, fmt.Errorf("unknown display style: %s", style)
+	}
+}
+
+// RenderStyle renders the puzzle as text in the given display style, falling
+// back to the superscript style for unknown styles.
+func (p *Puzzle) RenderStyle(style string) string {
+	renderer, err := NewRenderer(style)
+	if err != nil {
+		renderer = superscriptRenderer{}
+	}
+	text := renderer.Render(p)
+	if p.MaxMistakes > 0 {
+		text += "\n\n" + p.renderLives()
+	}
+	return text
+}
+
+// displayWords splits the puzzle into its words, leaving out the spaces and
+// any character that is neither a letter nor a symbol.
+func (p *Puzzle) displayWords() [][]*PuzzleChar {
+	var words [][]*PuzzleChar
+	var word []*PuzzleChar
+	for _, pc := range p.Chars {
+		switch {
+		case pc.Char == ' ':
+			if len(word) > 0 {
+				words = append(words, word)
+				word = nil
+			}
+		case pc.IsSymbol || unicode.IsLetter(pc.Char):
+			word = append(word, pc)
+		}
+	}
+	if len(word) > 0 {
+		words = append(words, word)
+	}
+	return words
+}
+
+func (pc *PuzzleChar) isBlank() bool {
+	return pc.IsHidden && !pc.IsGuessed
+}
+
+// cell returns what a letter cell shows: the letter, or _ while it is still
+// hidden, and its number. In encode mode the letters are always shown and it
+// is the number that stays hidden, shown as ?.
+func (p *Puzzle) cell(pc *PuzzleChar) (letter, value string) {
+	letter, value = string(pc.Char), strconv.Itoa(pc.Value)
+	if pc.isBlank() {
+		if p.Mode == ModeEncode {
+			value = "?"
+		} else {
+			letter = "_"
+		}
+	}
+	return letter, value
+}
+
+// superscriptRenderer is the original style, (A¹)(_¹¹), with one word per
+// line.
+type superscriptRenderer struct{}
+
+func (superscriptRenderer) Name() string { return StyleSuperscript }
+
+func (superscriptRenderer) Render(p *Puzzle) string {
+	var lines []string
+	for _, word := range p.displayWords() {
+		var line strings.Builder
+		for _, pc := range word {
+			if pc.IsSymbol {
+				line.WriteString("(" + string(pc.Char) + ")")
+				continue
+			}
+			letter, value := p.cell(pc)
+			line.WriteString("(" + letter + toSuperscript(value) + ")")
+		}
+		lines = append(lines, line.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// gridRenderer puts the numbers of each word in one row and the letters
+// right under them, with every column padded to the same width.
+type gridRenderer struct{}
+
+func (gridRenderer) Name() string { return StyleGrid }
+
+func (gridRenderer) Render(p *Puzzle) string {
+	width := 1
+	for _, pc := range p.Chars {
+		if n := len(strconv.Itoa(pc.Value)); n > width {
+			width = n
+		}
+	}
+
+	var blocks []string
+	for _, word := range p.displayWords() {
+		var numbers, letters []string
+		for _, pc := range word {
+			number, letter := "", string(pc.Char)
+			if !pc.IsSymbol {
+				letter, number = p.cell(pc)
+			}
+			numbers = append(numbers, padLeft(number, width))
+			letters = append(letters, padLeft(letter, width))
+		}
+		blocks = append(blocks, strings.TrimRight(strings.Join(numbers, " "), " ")+"\n"+strings.Join(letters, " "))
+	}
+	return strings.Join(blocks, "\n\n")
+}
+
+func padLeft(s string, width int) string {
+	if n := utf8.RuneCountInString(s); n < width {
+		return strings.Repeat(" ", width-n) + s
+	}
+	return s
+}
+
+// plainRenderer writes every letter as [value:letter] using only plain
+// characters, which screen readers read out cleanly.
+type plainRenderer struct{}
+
+func (plainRenderer) Name() string { return StylePlain }
+
+func (plainRenderer) Render(p *Puzzle) string {
+	var lines []string
+	for _, word := range p.displayWords() {
+		var tokens []string
+		for _, pc := range word {
+			if pc.IsSymbol {
+				tokens = append(tokens, "["+string(pc.Char)+"]")
+				continue
+			}
+			letter, value := p.cell(pc)
+			tokens = append(tokens, "["+value+":"+letter+"]")
+		}
+		lines = append(lines, strings.Join(tokens, " "))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// emojiRenderer marks every letter with a coloured box: blue for a letter
+// still to find, green for one that was guessed and white for a given one.
+type emojiRenderer struct{}
+
+func (emojiRenderer) Name() string { return StyleEmoji }
+
+func (emojiRenderer) Render(p *Puzzle) string {
+	var lines []string
+	for _, word := range p.displayWords() {
+		var tokens []string
+		for _, pc := range word {
+			letter, value := p.cell(pc)
+			switch {
+			case pc.IsSymbol:
+				tokens = append(tokens, string(pc.Char))
+			case pc.isBlank():
+				tokens = append(tokens, "🟦"+letter+value)
+			case pc.IsHidden:
+				tokens = append(tokens, "🟩"+letter+value)
+			default:
+				tokens = append(tokens, "⬜"+letter+value)
+			}
+		}
+		lines = append(lines, strings.Join(tokens, " "))
+	}
+	return strings.Join(lines, "\n")
+}
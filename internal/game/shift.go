@@ -0,0 +1,36 @@
+package game
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Puzzle modes. In the classic mode players fill in the hidden letters; in
+// the shift mode every letter is hidden and they have to work out the Caesar
+// shift from the numbers instead. ModeEncode is described in encode.go.
+const (
+	ModeClassic = ""
+	ModeShift   = "shift"
+)
+
+// GenerateShiftPuzzle creates a shift-recovery puzzle of the given
+// difficulty, see ModeShift.
+func (s *Service) GenerateShiftPuzzle(difficulty string, recent []string) (*Puzzle, error) {
+	return s.newPuzzle(difficulty, ModeShift, recent)
+}
+
+// CheckShift reports whether guess names the shift of a shift-recovery
+// puzzle. In modular mode shifts that differ by a multiple of the alphabet
+// size encode the same way and are all accepted. It returns an error if the
+// guess is not a whole number.
+func (s *Service) CheckShift(puzzle *Puzzle, guess string) (bool, error) {
+	shift, err := strconv.Atoi(strings.TrimSpace(guess))
+	if err != nil {
+		return false, err
+	}
+	if puzzle.ValueMode == ValueModeModular {
+		size := s.config.Alphabet(puzzle.Language).Size()
+		return mod(shift, size) == mod(puzzle.Shift, size), nil
+	}
+	return shift == puzzle.Shift, nil
+}
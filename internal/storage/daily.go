@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/supabase-community/postgrest-go"
+)
+
+type DailyResult struct {
+	UserID       int64      `json:"user_id"`
+	Date         string     `json:"date"`
+	FirstName    string     `json:"first_name"`
+	StartedAt    time.Time  `json:"started_at"`
+	FinishedAt   *time.Time `json:"finished_at,omitempty"`
+	Solved       bool       `json:"solved"`
+	Guesses      int        `json:"guesses"`
+	SolveSeconds int        `json:"solve_seconds"`
+}
+
+// GetDailyResult returns the user's attempt at the daily puzzle of the given
+// date, or nil if the user has not started it yet.
+func (s *Storage) GetDailyResult(userID int64, date string) (*DailyResult, error) {
+	var results []DailyResult
+	data, _, err := s.client.From("daily_results").Select("*", "exact", false).Eq("user_id", fmt.Sprintf("%d", userID)).Eq("date", date).Execute()
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &results); err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, nil
+	}
+	return &results[0], nil
+}
+
+func (s *Storage) StartDailyAttempt(userID int64, firstName, date string) error {
+	result := DailyResult{
+		UserID:    userID,
+		Date:      date,
+		FirstName: firstName,
+		StartedAt: time.Now().UTC(),
+	}
+	_, _, err := s.client.From("daily_results").Insert(result, false, "", "minimal", "").Execute()
+	return err
+}
+
+// DeleteDailyAttempt removes the user's attempt at the daily puzzle of the
+// given date, giving the attempt back when the puzzle could not be started.
+func (s *Storage) DeleteDailyAttempt(userID int64, date string) error {
+	_, _, err := s.client.From("daily_results").Delete("minimal", "").Eq("user_id", fmt.Sprintf("%d", userID)).Eq("date", date).Execute()
+	return err
+}
+
+func (s *Storage) FinishDailyAttempt(userID int64, date string, solved bool, guesses int) (*DailyResult, error) {
+	result, err := s.GetDailyResult(userID, date)
+	if err != nil {
+		return nil, fmt.Errorf("could not get daily attempt to finish: %w", err)
+	}
+	if result == nil {
+		return nil, fmt.Errorf("daily attempt not found")
+	}
+
+	finishedAt := time.Now().UTC()
+	result.FinishedAt = &finishedAt
+	result.Solved = solved
+	result.Guesses = guesses
+	result.SolveSeconds = int(finishedAt.Sub(result.StartedAt).Seconds())
+
+	updateData := map[string]interface{}{
+		"finished_at":   finishedAt,
+		"solved":        solved,
+		"guesses":       guesses,
+		"solve_seconds": result.SolveSeconds,
+	}
+	_, _, err = s.client.From("daily_results").Update(updateData, "", "minimal").Eq("user_id", fmt.Sprintf("%d", userID)).Eq("date", date).Execute()
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *Storage) GetDailyRanking(date string, limit int) ([]DailyResult, error) {
+	var results []DailyResult
+	orderOpts := postgrest.OrderOpts{
+		Ascending: true,
+	}
+	data, _, err := s.client.From("daily_results").Select("*", "exact", false).Eq("date", date).Eq("solved", "true").Order("solve_seconds", &orderOpts).Order("guesses", &orderOpts).Limit(limit, "").Execute()
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
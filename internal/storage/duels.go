@@ -0,0 +1,63 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"cryptowordgamebot/internal/game"
+
+	"github.com/supabase-community/supabase-go"
+)
+
+// DuelRepository keeps pending and active duels in the duels table so
+// challenges, escrowed bets and running duels survive a restart.
+type DuelRepository struct {
+	client *supabase.Client
+}
+
+type duelRow struct {
+	ID        string          `json:"id"`
+	State     json.RawMessage `json:"state"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+func NewDuelRepository(s *Storage) *DuelRepository {
+	return &DuelRepository{client: s.client}
+}
+
+func (r *DuelRepository) Save(duel *game.Duel) error {
+	state, err := json.Marshal(duel)
+	if err != nil {
+		return fmt.Errorf("could not encode duel: %w", err)
+	}
+	row := duelRow{ID: duel.ID, State: state, UpdatedAt: time.Now().UTC()}
+	_, _, err = r.client.From("duels").Upsert(row, "id", "minimal", "").Execute()
+	return err
+}
+
+func (r *DuelRepository) Delete(id string) error {
+	_, _, err := r.client.From("duels").Delete("minimal", "").Eq("id", id).Execute()
+	return err
+}
+
+func (r *DuelRepository) LoadAll() (map[string]*game.Duel, error) {
+	var rows []duelRow
+	data, _, err := r.client.From("duels").Select("*", "exact", false).Execute()
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &rows); err != nil {
+		return nil, err
+	}
+
+	duels := make(map[string]*game.Duel, len(rows))
+	for _, row := range rows {
+		var duel game.Duel
+		if err := json.Unmarshal(row.State, &duel); err != nil {
+			return nil, fmt.Errorf("could not decode duel %s: %w", row.ID, err)
+		}
+		duels[row.ID] = &duel
+	}
+	return duels, nil
+}
@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/supabase-community/postgrest-go"
+)
+
+const (
+	HistoryScopeUser = "user"
+	HistoryScopeChat = "chat"
+)
+
+// SeenPuzzle records that a user or chat was given a puzzle.
+type SeenPuzzle struct {
+	ScopeType string    `json:"scope_type"`
+	ScopeID   int64     `json:"scope_id"`
+	PuzzleID  string    `json:"puzzle_id"`
+	SeenAt    time.Time `json:"seen_at"`
+}
+
+func (s *Storage) RecordSeenPuzzle(scopeType string, scopeID int64, puzzleID string) error {
+	row := SeenPuzzle{
+		ScopeType: scopeType,
+		ScopeID:   scopeID,
+		PuzzleID:  puzzleID,
+		SeenAt:    time.Now().UTC(),
+	}
+	_, _, err := s.client.From("puzzle_history").Insert(row, false, "", "minimal", "").Execute()
+	return err
+}
+
+// GetRecentPuzzles returns the puzzles most recently seen by a user or chat,
+// most recent first.
+func (s *Storage) GetRecentPuzzles(scopeType string, scopeID int64, limit int) ([]SeenPuzzle, error) {
+	var rows []SeenPuzzle
+	orderOpts := postgrest.OrderOpts{
+		Ascending: false,
+	}
+	data, _, err := s.client.From("puzzle_history").Select("puzzle_id,seen_at", "exact", false).Eq("scope_type", scopeType).Eq("scope_id", fmt.Sprintf("%d", scopeID)).Order("seen_at", &orderOpts).Limit(limit, "").Execute()
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &rows); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
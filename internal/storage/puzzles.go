@@ -0,0 +1,63 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"cryptowordgamebot/internal/game"
+
+	"github.com/supabase-community/supabase-go"
+)
+
+// PuzzleStateRepository keeps the active puzzle of every chat in the
+// puzzle_states table so running games survive a restart.
+type PuzzleStateRepository struct {
+	client *supabase.Client
+}
+
+type puzzleState struct {
+	ChatID    int64           `json:"chat_id"`
+	State     json.RawMessage `json:"state"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+func NewPuzzleStateRepository(s *Storage) *PuzzleStateRepository {
+	return &PuzzleStateRepository{client: s.client}
+}
+
+func (r *PuzzleStateRepository) Save(chatID int64, puzzle *game.Puzzle) error {
+	state, err := json.Marshal(puzzle)
+	if err != nil {
+		return fmt.Errorf("could not encode puzzle state: %w", err)
+	}
+	row := puzzleState{ChatID: chatID, State: state, UpdatedAt: time.Now().UTC()}
+	_, _, err = r.client.From("puzzle_states").Upsert(row, "chat_id", "minimal", "").Execute()
+	return err
+}
+
+func (r *PuzzleStateRepository) Delete(chatID int64) error {
+	_, _, err := r.client.From("puzzle_states").Delete("minimal", "").Eq("chat_id", fmt.Sprintf("%d", chatID)).Execute()
+	return err
+}
+
+func (r *PuzzleStateRepository) LoadAll() (map[int64]*game.Puzzle, error) {
+	var rows []puzzleState
+	data, _, err := r.client.From("puzzle_states").Select("*", "exact", false).Execute()
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &rows); err != nil {
+		return nil, err
+	}
+
+	puzzles := make(map[int64]*game.Puzzle, len(rows))
+	for _, row := range rows {
+		var puzzle game.Puzzle
+		if err := json.Unmarshal(row.State, &puzzle); err != nil {
+			return nil, fmt.Errorf("could not decode puzzle state for chat %d: %w", row.ChatID, err)
+		}
+		puzzles[row.ChatID] = &puzzle
+	}
+	return puzzles, nil
+}
@@ -0,0 +1,106 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Reasons of a score event.
+const (
+	ScoreReasonSolve = "solve"
+	ScoreReasonRace  = "race"
+	ScoreReasonDuel  = "duel"
+)
+
+// ScoreEvent records points a user earned, or lost in a duel, in a chat.
+// Solved marks the event of the player who completed a puzzle, which also
+// carries how long the puzzle took.
+type ScoreEvent struct {
+	UserID       int64     `json:"user_id"`
+	ChatID       int64     `json:"chat_id"`
+	Points       int       `json:"points"`
+	Reason       string    `json:"reason"`
+	Solved       bool      `json:"solved"`
+	SolveSeconds int       `json:"solve_seconds,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type LeaderboardEntry struct {
+	UserID    int64  `json:"user_id"`
+	FirstName string `json:"first_name"`
+	Points    int64  `json:"points"`
+}
+
+// ChatStats summarizes the score events of a chat. The top solver is the
+// player who earned points in the most puzzles there.
+type ChatStats struct {
+	PuzzlesSolved       int    `json:"puzzles_solved"`
+	TopSolverID         int64  `json:"top_solver_id"`
+	TopSolverName       string `json:"top_solver_name"`
+	TopSolverPuzzles    int    `json:"top_solver_puzzles"`
+	AverageSolveSeconds int    `json:"average_solve_seconds"`
+}
+
+func (s *Storage) RecordScoreEvent(event ScoreEvent) error {
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now().UTC()
+	}
+	_, _, err := s.client.From("score_events").Insert(event, false, "", "minimal", "").Execute()
+	return err
+}
+
+// GetLeaderboard returns the players with the most points earned in a chat,
+// or in every chat when chatID is 0, best first. Only points earned in the
+// window [from, to) count; a zero time leaves that end of the window open.
+// Players whose total is not positive are left out.
+func (s *Storage) GetLeaderboard(chatID int64, from, to time.Time, limit int) ([]LeaderboardEntry, error) {
+	params := map[string]interface{}{
+		"p_chat_id": nil,
+		"p_from":    nil,
+		"p_to":      nil,
+		"p_limit":   limit,
+	}
+	if chatID != 0 {
+		params["p_chat_id"] = chatID
+	}
+	if !from.IsZero() {
+		params["p_from"] = from.UTC().Format(time.RFC3339)
+	}
+	if !to.IsZero() {
+		params["p_to"] = to.UTC().Format(time.RFC3339)
+	}
+
+	var entries []LeaderboardEntry
+	if err := s.rpc("score_leaderboard", params, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// GetChatStats counts the puzzles solved in a chat, finds its most active
+// solver and averages the solve times, all in the chat_score_stats function.
+func (s *Storage) GetChatStats(chatID int64) (*ChatStats, error) {
+	var results []ChatStats
+	if err := s.rpc("chat_score_stats", map[string]interface{}{"p_chat_id": chatID}, &results); err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return &ChatStats{}, nil
+	}
+	return &results[0], nil
+}
+
+// rpc calls a database function and decodes the rows it returns into to.
+// The client reports no status for function calls, so an error response
+// shows up as a body that does not decode.
+func (s *Storage) rpc(name string, params map[string]interface{}, to interface{}) error {
+	body := s.client.Rpc(name, "", params)
+	if body == "" {
+		return fmt.Errorf("rpc %s: no response", name)
+	}
+	if err := json.Unmarshal([]byte(body), to); err != nil {
+		return fmt.Errorf("rpc %s: %s", name, body)
+	}
+	return nil
+}
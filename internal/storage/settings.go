@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// ChatSettings holds the per-chat preferences kept in the chat_settings
+// table. Empty fields mean the chat uses the default.
+type ChatSettings struct {
+	ChatID       int64  `json:"chat_id"`
+	PuzzleFormat string `json:"puzzle_format,omitempty"`
+	DisplayStyle string `json:"display_style,omitempty"`
+	// WeeklyAnnouncement opts a group in to the announcement of last week's
+	// winners; LastWeeklyAnnouncement is the start date of the last week
+	// announced.
+	WeeklyAnnouncement     bool   `json:"weekly_announcement,omitempty"`
+	LastWeeklyAnnouncement string `json:"last_weekly_announcement,omitempty"`
+}
+
+// GetChatSettings returns the settings of a chat, or empty settings if the
+// chat never changed any.
+func (s *Storage) GetChatSettings(chatID int64) (*ChatSettings, error) {
+	var results []ChatSettings
+	data, _, err := s.client.From("chat_settings").Select("*", "exact", false).Eq("chat_id", fmt.Sprintf("%d", chatID)).Execute()
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &results); err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return &ChatSettings{ChatID: chatID}, nil
+	}
+	return &results[0], nil
+}
+
+func (s *Storage) UpdateChatPuzzleFormat(chatID int64, format string) error {
+	return s.updateChatSetting(chatID, "puzzle_format", format)
+}
+
+func (s *Storage) UpdateChatDisplayStyle(chatID int64, style string) error {
+	return s.updateChatSetting(chatID, "display_style", style)
+}
+
+func (s *Storage) UpdateChatWeeklyAnnouncement(chatID int64, enabled bool) error {
+	return s.updateChatSetting(chatID, "weekly_announcement", enabled)
+}
+
+// MarkWeeklyAnnouncement records that the winners of the week starting on
+// the given date have been announced in a chat.
+func (s *Storage) MarkWeeklyAnnouncement(chatID int64, week string) error {
+	return s.updateChatSetting(chatID, "last_weekly_announcement", week)
+}
+
+// GetWeeklyAnnouncementChats returns the settings of every chat that opted in
+// to the weekly winners announcement.
+func (s *Storage) GetWeeklyAnnouncementChats() ([]ChatSettings, error) {
+	var results []ChatSettings
+	data, _, err := s.client.From("chat_settings").Select("*", "exact", false).Eq("weekly_announcement", "true").Execute()
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (s *Storage) updateChatSetting(chatID int64, column string, value interface{}) error {
+	row := map[string]interface{}{"chat_id": chatID, column: value}
+	_, _, err := s.client.From("chat_settings").Upsert(row, "chat_id", "minimal", "").Execute()
+	return err
+}
@@ -3,6 +3,7 @@ package storage
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/supabase-community/supabase-go"
 	"github.com/supabase-community/postgrest-go"
@@ -17,6 +18,7 @@ type User struct {
 	Score        int64  `json:"score"`
 	ProfileTheme string `json:"profile_theme,omitempty"`
 	RevealLetter   int    `json:"reveal_letter,omitempty"`
+	DisplayStyle   string `json:"display_style,omitempty"`
 
 
 }
@@ -53,6 +55,42 @@ func (s *Storage) GetUser(userID int64) (*User, error) {
 	return &results[0], nil
 }
 
+// GetUserByUsername returns the user with the given Telegram username, which
+// is matched without regard to case and without the leading @. Telegram
+// usernames only have Latin letters, digits and underscores, so anything else,
+// including the ilike wildcards, is rejected before the query.
+func (s *Storage) GetUserByUsername(username string) (*User, error) {
+	var results []User
+	username = strings.TrimPrefix(username, "@")
+	if !isUsername(username) {
+		return nil, fmt.Errorf("user not found")
+	}
+	pattern := strings.ReplaceAll(username, "_", `\_`)
+	data, _, err := s.client.From("users").Select("*", "exact", false).Ilike("username", pattern).Execute()
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &results); err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("user not found")
+	}
+	return &results[0], nil
+}
+
+func isUsername(username string) bool {
+	if username == "" {
+		return false
+	}
+	for _, r := range username {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
+
 func (s *Storage) UpdateUserLanguage(userID int64, langCode string) error {
 	user, err := s.GetUser(userID)
 	if err != nil {
@@ -92,6 +130,12 @@ func (s *Storage) GetTopUsers(limit int) ([]User, error) {
 }
 
 // vvv FUNGSI BARU DITAMBAHKAN vvv
+func (s *Storage) UpdateUserDisplayStyle(userID int64, style string) error {
+	updateData := map[string]string{"display_style": style}
+	_, _, err := s.client.From("users").Update(updateData, "", "minimal").Eq("id", fmt.Sprintf("%d", userID)).Execute()
+	return err
+}
+
 func (s *Storage) UpdateUserProfileTheme(userID int64, theme string) error {
 	updateData := map[string]string{"profile_theme": theme}
 	_, _, err := s.client.From("users").Update(updateData, "", "minimal").Eq("id", fmt.Sprintf("%d", userID)).Execute()
@@ -0,0 +1,8 @@
+-- The active puzzle of every chat, stored as JSON so running games survive a
+-- restart. Saving upserts on chat_id.
+
+create table if not exists puzzle_states (
+    chat_id bigint primary key,
+    state jsonb not null,
+    updated_at timestamptz not null default now()
+);
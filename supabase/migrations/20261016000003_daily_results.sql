@@ -0,0 +1,17 @@
+-- One attempt per user at each day's shared puzzle. The date is the UTC day
+-- of the puzzle, and the primary key keeps a second attempt from being
+-- inserted.
+
+create table if not exists daily_results (
+    user_id bigint not null,
+    date date not null,
+    first_name text not null default '',
+    started_at timestamptz not null default now(),
+    finished_at timestamptz,
+    solved boolean not null default false,
+    guesses integer not null default 0,
+    solve_seconds integer not null default 0,
+    primary key (user_id, date)
+);
+
+create index if not exists daily_results_ranking_idx on daily_results (date, solved, solve_seconds, guesses);
@@ -0,0 +1,12 @@
+-- The puzzles each user and group chat has seen, read most recent first so
+-- new puzzles can prefer ones not seen lately.
+
+create table if not exists puzzle_history (
+    id bigint generated always as identity primary key,
+    scope_type text not null check (scope_type in ('user', 'chat')),
+    scope_id bigint not null,
+    puzzle_id text not null,
+    seen_at timestamptz not null default now()
+);
+
+create index if not exists puzzle_history_scope_seen_at_idx on puzzle_history (scope_type, scope_id, seen_at desc);
@@ -0,0 +1,7 @@
+-- Per-chat preferences. A chat gets a row the first time it changes a
+-- setting; settings are upserted on chat_id.
+
+create table if not exists chat_settings (
+    chat_id bigint primary key,
+    puzzle_format text check (puzzle_format in ('image', 'text'))
+);
@@ -0,0 +1,5 @@
+-- The text display style of puzzles, chosen by each user for their private
+-- chat and by group admins for a group. Null means the default style.
+
+alter table users add column if not exists display_style text;
+alter table chat_settings add column if not exists display_style text;
@@ -0,0 +1,8 @@
+-- Pending and active duels, stored as JSON so challenges, escrowed bets and
+-- running duels survive a restart. Saving upserts on id.
+
+create table if not exists duels (
+    id text primary key,
+    state jsonb not null,
+    updated_at timestamptz not null default now()
+);
@@ -0,0 +1,81 @@
+-- Score events and the aggregates read by the leaderboards and the group
+-- statistics. Events are summed in the database so a busy chat never has to
+-- send every row to the bot.
+
+create table if not exists score_events (
+    id bigint generated always as identity primary key,
+    user_id bigint not null,
+    chat_id bigint not null,
+    points integer not null,
+    reason text not null,
+    solved boolean not null default false,
+    solve_seconds integer,
+    created_at timestamptz not null default now()
+);
+
+create index if not exists score_events_chat_created_at_idx on score_events (chat_id, created_at);
+create index if not exists score_events_created_at_idx on score_events (created_at);
+
+-- score_leaderboard returns the players with the most points earned in a chat,
+-- or in every chat when p_chat_id is null, during [p_from, p_to). A null time
+-- leaves that end of the window open. Players who earned nothing, or lost
+-- more than they won, are left out.
+create or replace function score_leaderboard(p_chat_id bigint, p_from timestamptz, p_to timestamptz, p_limit integer)
+returns table (
+    user_id bigint,
+    first_name text,
+    points bigint
+)
+language sql stable
+as $$
+    select score_events.user_id, users.first_name, sum(score_events.points)::bigint as points
+    from score_events
+    left join users on users.id = score_events.user_id
+    where (p_chat_id is null or score_events.chat_id = p_chat_id)
+        and (p_from is null or score_events.created_at >= p_from)
+        and (p_to is null or score_events.created_at < p_to)
+    group by score_events.user_id, users.first_name
+    having sum(score_events.points) > 0
+    order by points desc, score_events.user_id
+    limit p_limit;
+$$;
+
+-- chat_score_stats counts the puzzles solved in a chat, averages their solve
+-- times and finds the player who earned points in the most puzzles there.
+create or replace function chat_score_stats(p_chat_id bigint)
+returns table (
+    puzzles_solved integer,
+    average_solve_seconds integer,
+    top_solver_id bigint,
+    top_solver_name text,
+    top_solver_puzzles integer
+)
+language sql stable
+as $$
+    with events as (
+        select * from score_events where chat_id = p_chat_id
+    ),
+    totals as (
+        select
+            count(*) filter (where solved) as solved,
+            avg(solve_seconds) filter (where solved) as average_seconds
+        from events
+    ),
+    top_solver as (
+        select user_id, count(*) as puzzles
+        from events
+        where solved or points > 0
+        group by user_id
+        order by puzzles desc, user_id
+        limit 1
+    )
+    select
+        totals.solved::integer,
+        coalesce(floor(totals.average_seconds), 0)::integer,
+        top_solver.user_id,
+        users.first_name,
+        coalesce(top_solver.puzzles, 0)::integer
+    from totals
+    left join top_solver on true
+    left join users on users.id = top_solver.user_id;
+$$;
@@ -0,0 +1,7 @@
+-- Groups that opted in to the announcement of last week's winners, and the
+-- start date of the last week announced in each.
+
+alter table chat_settings add column if not exists weekly_announcement boolean not null default false;
+alter table chat_settings add column if not exists last_weekly_announcement text;
+
+create index if not exists chat_settings_weekly_announcement_idx on chat_settings (chat_id) where weekly_announcement;